@@ -0,0 +1,177 @@
+package gorobocopy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robocopy takes ages in whole days.
+const ageDay = 24 * time.Hour
+
+// Robocopy reads the numbers from this one on as YYYYMMDD dates rather than days.
+const ageDateFloor = 1900
+
+var ageDaysPattern = regexp.MustCompile(`(?i)^(\d+)\s*d$`)
+
+// AgeFilter is the value of /maxage, /minage, /maxlad and /minlad: either an age, which
+// robocopy counts back from the start of the run in whole days, or a date. Robocopy
+// tells the two apart by value alone, reading numbers from 1900 on as YYYYMMDD dates,
+// so they are kept apart here and only written as a number on the command line.
+// The zero value is no filter.
+type AgeFilter struct {
+	age  time.Duration
+	date time.Time // midnight UTC of the date, so that filters can be compared
+}
+
+// AgeOf returns a filter for the given age. Robocopy only takes whole days, anything
+// else is reported by Validate.
+func AgeOf(age time.Duration) AgeFilter {
+	return AgeFilter{age: age}
+}
+
+// DateOf returns a filter for the calendar date of t, in the location of t.
+func DateOf(t time.Time) AgeFilter {
+	year, month, day := t.Date()
+	return AgeFilter{date: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseAgeFilter parses the value robocopy takes: a number of days below 1900, or a
+// date in the YYYYMMDD form.
+func ParseAgeFilter(s string) (AgeFilter, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return AgeFilter{}, fmt.Errorf("gorobocopy: invalid age %q: %w", s, ErrInvalidValue)
+	}
+	if n < ageDateFloor {
+		return AgeOf(time.Duration(n) * ageDay), nil
+	}
+	date, err := time.Parse("20060102", s)
+	if err != nil {
+		return AgeFilter{}, fmt.Errorf("gorobocopy: invalid date %q: %w", s, ErrInvalidValue)
+	}
+	return DateOf(date), nil
+}
+
+// Reports whether the filter is not set.
+func (a AgeFilter) IsZero() bool {
+	return a == AgeFilter{}
+}
+
+// Returns the age, and whether the filter is an age.
+func (a AgeFilter) Age() (time.Duration, bool) {
+	return a.age, a.age != 0
+}
+
+// Returns the date at midnight UTC, and whether the filter is a date.
+func (a AgeFilter) Date() (time.Time, bool) {
+	return a.date, !a.date.IsZero()
+}
+
+// Cutoff returns the time the filter stands for in a run started at now: the age before
+// now, or the start of the date in the location of now. It returns the zero time if the
+// filter is not set.
+func (a AgeFilter) Cutoff(now time.Time) time.Time {
+	switch {
+	case a.IsZero():
+		return time.Time{}
+	case !a.date.IsZero():
+		year, month, day := a.date.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	case a.age%ageDay == 0:
+		return now.AddDate(0, 0, -int(a.age/ageDay))
+	default:
+		return now.Add(-a.age)
+	}
+}
+
+// Returns why robocopy can't take the filter, or an empty string if it can.
+func (a AgeFilter) invalid() string {
+	switch {
+	case a.age < 0:
+		return "must not be negative"
+	case a.age%ageDay != 0:
+		return "must be a whole number of days"
+	case a.age >= ageDateFloor*ageDay:
+		return "must be less than 1900 days, robocopy reads larger numbers as dates"
+	case !a.date.IsZero() && (a.date.Year() < ageDateFloor || a.date.Year() > 9999):
+		return "must be a date from the years 1900 to 9999"
+	}
+	return ""
+}
+
+// Returns the value the way robocopy takes it, a number of days or a YYYYMMDD date.
+func (a AgeFilter) String() string {
+	if !a.date.IsZero() {
+		return a.date.Format("20060102")
+	}
+	return strconv.FormatInt(int64(a.age/ageDay), 10)
+}
+
+// MarshalText writes an age in days such as 30d, or as a duration if it isn't a whole
+// number of days, and a date such as 2024-01-31.
+func (a AgeFilter) MarshalText() ([]byte, error) {
+	switch {
+	case a.IsZero():
+		return nil, nil
+	case !a.date.IsZero():
+		return []byte(a.date.Format(time.DateOnly)), nil
+	case a.age%ageDay == 0:
+		return []byte(strconv.FormatInt(int64(a.age/ageDay), 10) + "d"), nil
+	default:
+		return []byte(a.age.String()), nil
+	}
+}
+
+// UnmarshalText reads the forms written by MarshalText, durations such as 36h, and the
+// plain numbers robocopy takes.
+func (a *AgeFilter) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		*a = AgeFilter{}
+		return nil
+	}
+	if m := ageDaysPattern.FindStringSubmatch(s); m != nil {
+		days, err := strconv.Atoi(m[1])
+		if err != nil || days >= ageDateFloor {
+			return fmt.Errorf("gorobocopy: invalid age %q, robocopy takes less than 1900 days: %w", s, ErrInvalidValue)
+		}
+		*a = AgeOf(time.Duration(days) * ageDay)
+		return nil
+	}
+	if date, err := time.Parse(time.DateOnly, s); err == nil {
+		*a = DateOf(date)
+		return nil
+	}
+	if _, err := strconv.Atoi(s); err == nil {
+		filter, err := ParseAgeFilter(s)
+		if err != nil {
+			return err
+		}
+		*a = filter
+		return nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("gorobocopy: invalid age %q, use days such as 30d or a date such as 2024-01-31: %w", s, ErrInvalidValue)
+	}
+	*a = AgeOf(age)
+	return nil
+}
+
+// UnmarshalJSON takes a JSON number as robocopy would, as well as a string.
+func (a *AgeFilter) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	switch {
+	case text == "null":
+		return nil
+	case strings.HasPrefix(text, `"`):
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	return a.UnmarshalText([]byte(text))
+}
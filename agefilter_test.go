@@ -0,0 +1,94 @@
+package gorobocopy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseAgeFilter(t *testing.T) {
+	tests := []struct {
+		text string
+		want AgeFilter
+	}{
+		{"0", AgeFilter{}},
+		{"7", AgeOf(7 * 24 * time.Hour)},
+		{"1899", AgeOf(1899 * 24 * time.Hour)},
+		{"19000101", DateOf(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{"20240131", DateOf(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))},
+	}
+	for _, test := range tests {
+		have, err := ParseAgeFilter(test.text)
+		if err != nil || have != test.want {
+			t.Errorf("%q: have: %v %v, want: %v", test.text, have, err, test.want)
+		}
+		if err == nil && !have.IsZero() && have.String() != test.text {
+			t.Errorf("%q: written as %s", test.text, have)
+		}
+	}
+	for _, text := range []string{"", "-1", "1900", "20241301", "2024-01-31", "7d"} {
+		if _, err := ParseAgeFilter(text); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("%q: have: %v", text, err)
+		}
+	}
+}
+
+func TestAgeFilterText(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  AgeFilter
+		write string
+	}{
+		{"30d", AgeOf(30 * 24 * time.Hour), "30d"},
+		{"30 D", AgeOf(30 * 24 * time.Hour), "30d"},
+		{"48h", AgeOf(48 * time.Hour), "2d"},
+		{"36h", AgeOf(36 * time.Hour), "36h0m0s"},
+		{"2024-01-31", DateOf(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)), "2024-01-31"},
+		{"20240131", DateOf(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)), "2024-01-31"},
+		{"12", AgeOf(12 * 24 * time.Hour), "12d"},
+		{"", AgeFilter{}, ""},
+	}
+	for _, test := range tests {
+		var have AgeFilter
+		if err := have.UnmarshalText([]byte(test.text)); err != nil || have != test.want {
+			t.Errorf("%q: have: %v %v, want: %v", test.text, have, err, test.want)
+		}
+		if text, _ := have.MarshalText(); string(text) != test.write {
+			t.Errorf("%q: written as %q, want: %q", test.text, text, test.write)
+		}
+	}
+	for _, text := range []string{"soon", "1900d", "2024-02-30", "20241301"} {
+		var have AgeFilter
+		if err := have.UnmarshalText([]byte(text)); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("%q: have: %v", text, err)
+		}
+	}
+
+	var fso FileSelectionOptions
+	if err := json.Unmarshal([]byte(`{"maxage":30,"minage":"2d","minlad":20240131}`), &fso); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(fso)
+	if want := `{"maxage":"30d","minage":"2d","minlad":"2024-01-31"}`; string(data) != want {
+		t.Errorf("have: %s, want: %s", data, want)
+	}
+}
+
+func TestAgeFilterCutoff(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.Local)
+	tests := []struct {
+		filter AgeFilter
+		want   time.Time
+	}{
+		{AgeFilter{}, time.Time{}},
+		{AgeOf(10 * 24 * time.Hour), time.Date(2024, 2, 29, 15, 30, 0, 0, time.Local)},
+		{AgeOf(90 * time.Minute), time.Date(2024, 3, 10, 14, 0, 0, 0, time.Local)},
+		{DateOf(time.Date(2024, 1, 31, 23, 0, 0, 0, time.FixedZone("x", -5*3600))), time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		if have := test.filter.Cutoff(now); !have.Equal(test.want) {
+			t.Errorf("%v: have: %v, want: %v", test.filter, have, test.want)
+		}
+	}
+}
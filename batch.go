@@ -0,0 +1,294 @@
+package gorobocopy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+var (
+	// The job was skipped because a job it runs after didn't succeed.
+	ErrDependencyFailed = errors.New("a dependency didn't succeed")
+	// The job was skipped because another job failed with Batch.StopOnFailure set.
+	ErrBatchStopped = errors.New("the batch stopped after a failure")
+)
+
+// BatchJob is a command run by a Batch.
+type BatchJob struct {
+	// Names the job in the report and in the After lists of other jobs. Names must be
+	// unique, jobs without one can't be depended on.
+	Name string
+	// The command to run. A command can't be shared by jobs, as it records the result of
+	// its last run, and Run refuses a batch that does.
+	Command *Robocopy
+	// The names of the jobs that must succeed before this one starts. If one of them
+	// fails or is skipped, this job is skipped too.
+	After []string
+	// How long the job may run before it is stopped and fails. Zero uses Batch.Timeout.
+	Timeout time.Duration
+	// Passed to RunContext. The writers must be safe to use from several jobs at once if
+	// they are shared.
+	Options RunOptions
+}
+
+// Batch runs many commands, at most Concurrency of them at a time. The jobs are started
+// in order as soon as the jobs they run after succeeded and a slot is free. Every command
+// runs with its own executor, so fake executors such as the one of package robocopytest
+// work as well as robocopy or the engine.
+type Batch struct {
+	Jobs []BatchJob
+	// The number of jobs running at once. Zero or less runs them one at a time.
+	Concurrency int
+	// How long each job may run, unless it has its own timeout. Zero means no limit.
+	Timeout time.Duration
+	// Starts no more jobs once one fails. The jobs already running are finished and the
+	// others are skipped with ErrBatchStopped. Otherwise the batch continues with the
+	// jobs that don't depend on the failed one.
+	StopOnFailure bool
+}
+
+// BatchStatus is how a job of a batch ended.
+type BatchStatus string
+
+const (
+	BatchSucceeded BatchStatus = "succeeded" // Ran with an exit code below 8.
+	BatchFailed    BatchStatus = "failed"    // Ran with an exit code of 8 or higher, timed out or couldn't start.
+	BatchSkipped   BatchStatus = "skipped"   // Never started, see Err for the reason.
+)
+
+// BatchResult is the outcome of one job of a batch.
+type BatchResult struct {
+	Name     string          `json:"name"`
+	Status   BatchStatus     `json:"status"`
+	ExitCode ExitCode        `json:"exitCode"`
+	Summary  *output.Summary `json:"summary,omitempty"`
+	Duration time.Duration   `json:"duration"`
+	// Why the job failed or was skipped: an *ExitError, a *ValidationError or
+	// *SafetyError, context.DeadlineExceeded for a timeout, ErrDependencyFailed and so on.
+	Err error `json:"-"`
+}
+
+// MarshalJSON writes the error as its message.
+func (r BatchResult) MarshalJSON() ([]byte, error) {
+	type result BatchResult
+	var message string
+	if r.Err != nil {
+		message = r.Err.Error()
+	}
+	return json.Marshal(struct {
+		result
+		Error string `json:"error,omitempty"`
+	}{result(r), message})
+}
+
+// BatchReport is the outcome of a batch returned by Batch.Run.
+type BatchReport struct {
+	Jobs      []BatchResult `json:"jobs"` // In the order of Batch.Jobs.
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"`
+	// The bits of the exit codes of all the jobs that ran, leaving out the -1 of the jobs
+	// that were stopped or couldn't start.
+	ExitCode ExitCode      `json:"exitCode"`
+	Duration time.Duration `json:"duration"`
+	// The rows of the summaries of all the jobs added up.
+	Dirs  output.Counts `json:"dirs"`
+	Files output.Counts `json:"files"`
+	Bytes output.Counts `json:"bytes"`
+}
+
+// Reports whether every job of the batch succeeded.
+func (r *BatchReport) Success() bool {
+	return r.Failed == 0 && r.Skipped == 0
+}
+
+// Checks the jobs and returns the index of every named one.
+func (b *Batch) check() (map[string]int, error) {
+	names := map[string]int{}
+	commands := map[*Robocopy]int{}
+	for i, job := range b.Jobs {
+		if job.Command == nil {
+			return nil, fmt.Errorf("gorobocopy: batch job %d %q has no command", i, job.Name)
+		}
+		if j, ok := commands[job.Command]; ok {
+			return nil, fmt.Errorf("gorobocopy: batch job %d %q has the command of job %d %q", i, job.Name, j, b.Jobs[j].Name)
+		}
+		commands[job.Command] = i
+		if job.Name == "" {
+			continue
+		}
+		if _, ok := names[job.Name]; ok {
+			return nil, fmt.Errorf("gorobocopy: batch job name %q is used twice", job.Name)
+		}
+		names[job.Name] = i
+	}
+	for _, job := range b.Jobs {
+		for _, name := range job.After {
+			if _, ok := names[name]; !ok {
+				return nil, fmt.Errorf("gorobocopy: batch job %q runs after unknown job %q", job.Name, name)
+			}
+		}
+	}
+	// Walks the dependencies depth first, a job met again while its own are walked
+	// closes a cycle.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(b.Jobs))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("gorobocopy: batch job %d %q depends on itself", i, b.Jobs[i].Name)
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, name := range b.Jobs[i].After {
+			if err := visit(names[name]); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range b.Jobs {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// Run runs the jobs and returns the report once all of them ended. It only returns an
+// error, and no report, if the jobs are inconsistent: a job without a command, a command
+// or name used twice, or dependencies on unknown jobs or in a cycle. The failures of the
+// jobs are in the report. When the context is done the running jobs are stopped as
+// RunContext does, the others are skipped, and the report is returned with the context
+// error.
+func (b *Batch) Run(ctx context.Context) (*BatchReport, error) {
+	names, err := b.check()
+	if err != nil {
+		return nil, err
+	}
+	limit := max(b.Concurrency, 1)
+	start := time.Now()
+	report := &BatchReport{Jobs: make([]BatchResult, len(b.Jobs))}
+	const (
+		pending = iota
+		running
+		ended
+	)
+	state := make([]int, len(b.Jobs))
+	finished := make(chan int)
+	active, stopped := 0, false
+	for {
+		// Skipping a job can make others skippable, so go over the jobs until nothing changes.
+		for changed := true; changed; {
+			changed = false
+			for i, job := range b.Jobs {
+				if state[i] != pending {
+					continue
+				}
+				skip, ready := error(nil), true
+				for _, name := range job.After {
+					dep := names[name]
+					if state[dep] != ended {
+						ready = false
+					} else if report.Jobs[dep].Status != BatchSucceeded {
+						skip = fmt.Errorf("%w: %q", ErrDependencyFailed, name)
+					}
+				}
+				switch {
+				case ctx.Err() != nil:
+					skip = ctx.Err()
+				case stopped:
+					skip = ErrBatchStopped
+				}
+				if skip != nil {
+					report.Jobs[i] = BatchResult{Name: job.Name, Status: BatchSkipped, Err: skip}
+					state[i] = ended
+					changed = true
+					continue
+				}
+				if ready && active < limit {
+					state[i] = running
+					active++
+					go func(i int) {
+						report.Jobs[i] = b.runJob(ctx, b.Jobs[i])
+						finished <- i
+					}(i)
+				}
+			}
+		}
+		if active == 0 {
+			break
+		}
+		i := <-finished
+		active--
+		state[i] = ended
+		if report.Jobs[i].Status == BatchFailed && b.StopOnFailure {
+			stopped = true
+		}
+	}
+
+	report.Duration = time.Since(start)
+	for _, result := range report.Jobs {
+		switch result.Status {
+		case BatchSucceeded:
+			report.Succeeded++
+		case BatchFailed:
+			report.Failed++
+		case BatchSkipped:
+			report.Skipped++
+		}
+		if result.ExitCode > 0 {
+			report.ExitCode |= result.ExitCode
+		}
+		if s := result.Summary; s != nil {
+			addCounts(&report.Dirs, s.Dirs)
+			addCounts(&report.Files, s.Files)
+			addCounts(&report.Bytes, s.Bytes)
+		}
+	}
+	return report, ctx.Err()
+}
+
+// Runs one job with its timeout.
+func (b *Batch) runJob(ctx context.Context, job BatchJob) BatchResult {
+	timeout := job.Timeout
+	if timeout == 0 {
+		timeout = b.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	result, err := job.Command.RunContext(ctx, job.Options)
+	r := BatchResult{Name: job.Name, Status: BatchSucceeded, Duration: time.Since(start), Err: err}
+	if result != nil {
+		r.ExitCode, r.Summary, r.Duration = result.ExitCode, result.Summary, result.Duration
+	}
+	if err != nil {
+		r.Status = BatchFailed
+	}
+	return r
+}
+
+// Adds the row b to a.
+func addCounts(a *output.Counts, b output.Counts) {
+	a.Total += b.Total
+	a.Copied += b.Copied
+	a.Skipped += b.Skipped
+	a.Mismatch += b.Mismatch
+	a.Failed += b.Failed
+	a.Extras += b.Extras
+}
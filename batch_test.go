@@ -0,0 +1,287 @@
+package gorobocopy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/output"
+	"github.com/aggellos2001/go-robocopy/robocopytest"
+)
+
+const batchSummary = "\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"               Total    Copied   Skipped  Mismatch    FAILED    Extras\r\n" +
+	"    Dirs :         2         1         1         0         0         0\r\n" +
+	"   Files :         4         2         1         0         1         1\r\n" +
+	"   Bytes :      3158      2148        10         0      1000        20\r\n" +
+	"   Times :   0:00:05   0:00:00                       0:00:05   0:00:00\r\n" +
+	"   Ended : Monday, January 1, 2024 10:00:05 AM\r\n"
+
+// Returns a job named after its source directory, run with the executor.
+func batchJob(name string, executor gorobocopy.Executor, after ...string) gorobocopy.BatchJob {
+	r := gorobocopy.NewRobocopy(`C:\`+name, `D:\backup\`+name)
+	r.SetExecutor(executor)
+	return gorobocopy.BatchJob{Name: name, Command: r, After: after}
+}
+
+// Returns a fake answering every job with its response, and the order the jobs started in.
+func batchFake(responses map[string]robocopytest.Response) (*robocopytest.Fake, func() []string) {
+	var mu sync.Mutex
+	var started []string
+	fake := &robocopytest.Fake{Respond: func(args []string) robocopytest.Response {
+		name := strings.TrimPrefix(args[0], `C:\`)
+		mu.Lock()
+		started = append(started, name)
+		mu.Unlock()
+		return responses[name]
+	}}
+	return fake, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return started
+	}
+}
+
+func TestBatch(t *testing.T) {
+	failed := robocopytest.Response{ExitCode: gorobocopy.FatalError}
+	tests := []struct {
+		name          string
+		jobs          func(fake *robocopytest.Fake) []gorobocopy.BatchJob
+		responses     map[string]robocopytest.Response
+		stopOnFailure bool
+		started       string
+		statuses      string
+		errs          map[string]error
+	}{
+		{
+			name: "in order",
+			jobs: func(fake *robocopytest.Fake) []gorobocopy.BatchJob {
+				return []gorobocopy.BatchJob{batchJob("a", fake), batchJob("b", fake), batchJob("c", fake)}
+			},
+			started:  "a b c",
+			statuses: "succeeded succeeded succeeded",
+		},
+		{
+			name: "dependencies",
+			jobs: func(fake *robocopytest.Fake) []gorobocopy.BatchJob {
+				return []gorobocopy.BatchJob{batchJob("a", fake, "c"), batchJob("b", fake, "a"), batchJob("c", fake)}
+			},
+			started:  "c a b",
+			statuses: "succeeded succeeded succeeded",
+		},
+		{
+			name: "failed dependency",
+			jobs: func(fake *robocopytest.Fake) []gorobocopy.BatchJob {
+				return []gorobocopy.BatchJob{batchJob("a", fake), batchJob("b", fake, "a"), batchJob("c", fake, "b"), batchJob("d", fake)}
+			},
+			responses: map[string]robocopytest.Response{"a": failed},
+			started:   "a d",
+			statuses:  "failed skipped skipped succeeded",
+			errs:      map[string]error{"b": gorobocopy.ErrDependencyFailed, "c": gorobocopy.ErrDependencyFailed},
+		},
+		{
+			name: "stop on failure",
+			jobs: func(fake *robocopytest.Fake) []gorobocopy.BatchJob {
+				return []gorobocopy.BatchJob{batchJob("a", fake), batchJob("b", fake), batchJob("c", fake)}
+			},
+			responses:     map[string]robocopytest.Response{"b": failed},
+			stopOnFailure: true,
+			started:       "a b",
+			statuses:      "succeeded failed skipped",
+			errs:          map[string]error{"c": gorobocopy.ErrBatchStopped},
+		},
+		{
+			name: "start failure",
+			jobs: func(fake *robocopytest.Fake) []gorobocopy.BatchJob {
+				return []gorobocopy.BatchJob{batchJob("a", fake), batchJob("b", fake)}
+			},
+			responses: map[string]robocopytest.Response{"a": {Err: errors.New("robocopy not found")}},
+			started:   "a b",
+			statuses:  "failed succeeded",
+		},
+	}
+	for _, test := range tests {
+		fake, started := batchFake(test.responses)
+		batch := gorobocopy.Batch{Jobs: test.jobs(fake), StopOnFailure: test.stopOnFailure}
+		report, err := batch.Run(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if have := strings.Join(started(), " "); have != test.started {
+			t.Errorf("%s: started %s, want: %s", test.name, have, test.started)
+		}
+		var statuses []string
+		for _, result := range report.Jobs {
+			statuses = append(statuses, string(result.Status))
+			if want := test.errs[result.Name]; want != nil && !errors.Is(result.Err, want) {
+				t.Errorf("%s: %s: have: %v, want: %v", test.name, result.Name, result.Err, want)
+			}
+		}
+		if have := strings.Join(statuses, " "); have != test.statuses {
+			t.Errorf("%s: have: %s, want: %s", test.name, have, test.statuses)
+		}
+		if n := report.Succeeded + report.Failed + report.Skipped; n != len(report.Jobs) || report.Success() != (report.Succeeded == n) {
+			t.Errorf("%s: counted %d of %d jobs", test.name, n, len(report.Jobs))
+		}
+	}
+}
+
+// Counts the runs going on at once.
+type countingExecutor struct {
+	mu           sync.Mutex
+	active, peak int
+}
+
+func (e *countingExecutor) Execute(ctx context.Context, cmd gorobocopy.Command) (gorobocopy.ExitCode, error) {
+	e.mu.Lock()
+	e.active++
+	e.peak = max(e.peak, e.active)
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.active--
+		e.mu.Unlock()
+	}()
+	select {
+	case <-time.After(10 * time.Millisecond):
+		return 0, nil
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+}
+
+func TestBatchConcurrency(t *testing.T) {
+	for _, limit := range []int{0, 1, 3} {
+		executor := &countingExecutor{}
+		var jobs []gorobocopy.BatchJob
+		for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+			jobs = append(jobs, batchJob(name, executor))
+		}
+		batch := gorobocopy.Batch{Jobs: jobs, Concurrency: limit}
+		report, err := batch.Run(context.Background())
+		if err != nil || !report.Success() {
+			t.Fatalf("%d: %v, %+v", limit, err, report)
+		}
+		if want := max(limit, 1); executor.peak != want {
+			t.Errorf("%d: have %d jobs at once, want: %d", limit, executor.peak, want)
+		}
+	}
+}
+
+func TestBatchTimeout(t *testing.T) {
+	fake, _ := batchFake(map[string]robocopytest.Response{
+		"slow": {Delay: time.Minute},
+		"fast": {Delay: time.Millisecond},
+	})
+	slow, fast, after := batchJob("slow", fake), batchJob("fast", fake), batchJob("after", fake, "slow")
+	fast.Timeout = time.Minute
+	batch := gorobocopy.Batch{Jobs: []gorobocopy.BatchJob{slow, fast, after}, Concurrency: 2, Timeout: 20 * time.Millisecond}
+	report, err := batch.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := report.Jobs[0]; r.Status != gorobocopy.BatchFailed || !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Errorf("slow: have: %s, %v", r.Status, r.Err)
+	}
+	if r := report.Jobs[1]; r.Status != gorobocopy.BatchSucceeded {
+		t.Errorf("fast: have: %s, %v", r.Status, r.Err)
+	}
+	if r := report.Jobs[2]; r.Status != gorobocopy.BatchSkipped || !errors.Is(r.Err, gorobocopy.ErrDependencyFailed) {
+		t.Errorf("after: have: %s, %v", r.Status, r.Err)
+	}
+	if report.ExitCode != 0 {
+		t.Errorf("exit code: have: %d", report.ExitCode)
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	fake, started := batchFake(map[string]robocopytest.Response{"a": {Delay: time.Minute}})
+	batch := gorobocopy.Batch{Jobs: []gorobocopy.BatchJob{batchJob("a", fake), batchJob("b", fake)}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report, err := batch.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || report == nil {
+		t.Fatalf("have: %v", err)
+	}
+	if report.Failed != 1 || report.Skipped != 1 || !errors.Is(report.Jobs[1].Err, context.DeadlineExceeded) {
+		t.Errorf("have: %+v", report)
+	}
+	if have := started(); len(have) != 1 {
+		t.Errorf("started: %q", have)
+	}
+}
+
+func TestBatchReport(t *testing.T) {
+	fake, _ := batchFake(map[string]robocopytest.Response{
+		"a": {Stdout: batchSummary, ExitCode: gorobocopy.FilesCopied | gorobocopy.ExtrasDetected},
+		"b": {Stdout: batchSummary, ExitCode: gorobocopy.FilesCopied | gorobocopy.CopyFailures},
+		"c": {ExitCode: gorobocopy.MismatchesDetected},
+	})
+	batch := gorobocopy.Batch{Jobs: []gorobocopy.BatchJob{batchJob("a", fake), batchJob("b", fake), batchJob("c", fake)}, Concurrency: 3}
+	report, err := batch.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := gorobocopy.FilesCopied | gorobocopy.ExtrasDetected | gorobocopy.MismatchesDetected | gorobocopy.CopyFailures; report.ExitCode != want {
+		t.Errorf("exit code: have: %s, want: %s", report.ExitCode, want)
+	}
+	if report.Succeeded != 2 || report.Failed != 1 || report.Success() {
+		t.Errorf("have: %d succeeded, %d failed", report.Succeeded, report.Failed)
+	}
+	var exitErr *gorobocopy.ExitError
+	if r := report.Jobs[1]; !errors.As(r.Err, &exitErr) || r.Summary == nil {
+		t.Errorf("b: have: %v, %v", r.Err, r.Summary)
+	}
+	if want := (output.Counts{Total: 8, Copied: 4, Skipped: 2, Failed: 2, Extras: 2}); report.Files != want {
+		t.Errorf("files: have: %+v, want: %+v", report.Files, want)
+	}
+	if report.Bytes.Copied != 2*2148 || report.Dirs.Total != 4 {
+		t.Errorf("have: %+v, %+v", report.Dirs, report.Bytes)
+	}
+
+	data, err := json.Marshal(report.Jobs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["name"] != "b" || decoded["status"] != "failed" || decoded["error"] != report.Jobs[1].Err.Error() || decoded["summary"] == nil {
+		t.Errorf("have: %s", data)
+	}
+}
+
+func TestBatchInvalid(t *testing.T) {
+	fake := robocopytest.New()
+	shared := batchJob("a", fake)
+	tests := []struct {
+		name string
+		jobs []gorobocopy.BatchJob
+		err  string
+	}{
+		{"no command", []gorobocopy.BatchJob{{Name: "a"}}, `job 0 "a" has no command`},
+		{"duplicate name", []gorobocopy.BatchJob{batchJob("a", fake), batchJob("a", fake)}, `"a" is used twice`},
+		{"shared command", []gorobocopy.BatchJob{shared, {Name: "b", Command: shared.Command}}, `job 1 "b" has the command of job 0 "a"`},
+		{"unnamed shared command", []gorobocopy.BatchJob{shared, {Command: shared.Command}}, `job 1 "" has the command of job 0 "a"`},
+		{"unknown dependency", []gorobocopy.BatchJob{batchJob("a", fake, "b")}, `unknown job "b"`},
+		{"cycle", []gorobocopy.BatchJob{batchJob("a", fake, "c"), batchJob("b", fake, "a"), batchJob("c", fake, "b")}, `job 0 "a" depends on itself`},
+		{"self", []gorobocopy.BatchJob{batchJob("x", fake), batchJob("a", fake, "a")}, `job 1 "a" depends on itself`},
+	}
+	for _, test := range tests {
+		batch := gorobocopy.Batch{Jobs: test.jobs}
+		if report, err := batch.Run(context.Background()); err == nil || report != nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: have: %v, %v, want: %s", test.name, report, err, test.err)
+		}
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("ran: %q", calls)
+	}
+}
@@ -0,0 +1,155 @@
+package gorobocopy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aggellos2001/go-robocopy/flags/unitflags"
+	"github.com/aggellos2001/go-robocopy/types"
+)
+
+// ByteSize is a number of bytes, as taken by the size switches of robocopy. It is written
+// with the largest unit that divides it, as in 10m, and parsed from forms such as 512k,
+// 1.5GB or a plain number of bytes.
+type ByteSize int64
+
+const (
+	Byte     ByteSize = 1
+	Kilobyte          = 1 << 10 * Byte
+	Megabyte          = 1 << 10 * Kilobyte
+	Gigabyte          = 1 << 10 * Megabyte
+)
+
+// MinIORate is the lowest rate robocopy throttles to. A lower /iorate is raised to it.
+const MinIORate = 512 * Kilobyte
+
+// A size doesn't fit in an int64 number of bytes.
+var ErrSizeOverflow = errors.New("size overflows int64")
+
+// The units from the largest down, as written by String.
+var byteSizeUnits = []unitflags.UnitFlags{unitflags.Gigabytes, unitflags.Megabytes, unitflags.Kilobytes}
+
+var byteSizePattern = regexp.MustCompile(`(?i)^\s*(\d+)(?:\.(\d+))?\s*([kmg]?)b?\s*$`)
+
+// NewByteSize returns n of the unit, where the zero unit is plain bytes. It fails if the
+// unit is unknown or the size overflows.
+func NewByteSize(n int64, unit unitflags.UnitFlags) (ByteSize, error) {
+	if unit.Bytes() == 0 {
+		return 0, fmt.Errorf("gorobocopy: unknown unit %s: %w", unit, ErrInvalidValue)
+	}
+	return ByteSize(n).Mul(unit.Bytes())
+}
+
+// ByteSizeFromPair converts the pair of count and unit the size fields used to be.
+func ByteSizeFromPair(p types.Pair[int, unitflags.UnitFlags]) (ByteSize, error) {
+	return NewByteSize(int64(p.First), p.Second)
+}
+
+// ParseByteSize parses a size such as 512k, 10 MB or 1.5GB, case-insensitively. A number
+// without a unit is a number of bytes, and fractions of a byte are dropped.
+func ParseByteSize(s string) (ByteSize, error) {
+	m := byteSizePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("gorobocopy: invalid size %q: %w", s, ErrInvalidValue)
+	}
+	unit := unitflags.UnitFlags(0)
+	if m[3] != "" {
+		unit = unitflags.UnitFlags(1 << strings.IndexAny("kmg", strings.ToLower(m[3])))
+	}
+	overflow := fmt.Errorf("gorobocopy: invalid size %q: %w: %w", s, ErrInvalidValue, ErrSizeOverflow)
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, overflow
+	}
+	size, err := NewByteSize(n, unit)
+	if err != nil {
+		return 0, overflow
+	}
+	if fraction := m[2]; fraction != "" {
+		// Digits past the ninth are below a byte even in gigabytes.
+		fraction = fraction[:min(len(fraction), 9)]
+		f, _ := strconv.ParseInt(fraction, 10, 64)
+		bytes := f * unit.Bytes() / int64(math.Pow10(len(fraction)))
+		if size, err = size.Add(ByteSize(bytes)); err != nil {
+			return 0, overflow
+		}
+	}
+	return size, nil
+}
+
+// Add returns b+other, or ErrSizeOverflow if the sum doesn't fit in an int64.
+func (b ByteSize) Add(other ByteSize) (ByteSize, error) {
+	if (other > 0 && b > math.MaxInt64-other) || (other < 0 && b < math.MinInt64-other) {
+		return 0, ErrSizeOverflow
+	}
+	return b + other, nil
+}
+
+// Mul returns b*n, or ErrSizeOverflow if the product doesn't fit in an int64.
+func (b ByteSize) Mul(n int64) (ByteSize, error) {
+	if b == 0 || n == 0 {
+		return 0, nil
+	}
+	product := b * ByteSize(n)
+	if product/ByteSize(n) != b || (n == -1 && b == math.MinInt64) {
+		return 0, ErrSizeOverflow
+	}
+	return product, nil
+}
+
+// Returns the size as a count of the largest unit that divides it.
+func (b ByteSize) split() (int64, unitflags.UnitFlags) {
+	if b != 0 {
+		for _, unit := range byteSizeUnits {
+			if int64(b)%unit.Bytes() == 0 {
+				return int64(b) / unit.Bytes(), unit
+			}
+		}
+	}
+	return int64(b), 0
+}
+
+// Returns the size the way robocopy takes it, such as 10m, 512k or 1000.
+func (b ByteSize) String() string {
+	n, unit := b.split()
+	return strconv.FormatInt(n, 10) + unit.String()
+}
+
+// MarshalText writes the size such as 10MB, 512KB or 1000.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	n, unit := b.split()
+	text := strconv.FormatInt(n, 10)
+	if unit != 0 {
+		text += strings.ToUpper(unit.String()) + "B"
+	}
+	return []byte(text), nil
+}
+
+// UnmarshalText parses the size as ParseByteSize does.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// UnmarshalJSON takes a JSON number of bytes as well as a string.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	switch {
+	case text == "null":
+		return nil
+	case strings.HasPrefix(text, `"`):
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	return b.UnmarshalText([]byte(text))
+}
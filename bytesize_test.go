@@ -0,0 +1,139 @@
+package gorobocopy
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/aggellos2001/go-robocopy/flags/unitflags"
+	"github.com/aggellos2001/go-robocopy/types"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		text string
+		want ByteSize
+	}{
+		{"0", 0},
+		{"1000", 1000},
+		{"100b", 100},
+		{"512k", 512 * Kilobyte},
+		{"512KB", 512 * Kilobyte},
+		{"10 MB", 10 * Megabyte},
+		{"1.5GB", 1536 * Megabyte},
+		{" 2g ", 2 * Gigabyte},
+		{"0.5k", 512},
+		{"1.0001k", 1024},
+		{"1.5", 1},
+		{"8589934591g", 8589934591 * Gigabyte},
+		{"9223372036854775807", math.MaxInt64},
+		{"8589934591.999999999g", math.MaxInt64 - 1},
+	}
+	for _, test := range tests {
+		have, err := ParseByteSize(test.text)
+		if err != nil || have != test.want {
+			t.Errorf("%q: have: %d %v, want: %d", test.text, have, err, test.want)
+		}
+	}
+}
+
+func TestParseByteSizeErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		overflow bool
+	}{
+		{"", false},
+		{"k", false},
+		{"-1k", false},
+		{"10t", false},
+		{"1.k", false},
+		{"1e3", false},
+		{"8589934592g", true},
+		{"9223372036854775808", true},
+	}
+	for _, test := range tests {
+		_, err := ParseByteSize(test.text)
+		if !errors.Is(err, ErrInvalidValue) || errors.Is(err, ErrSizeOverflow) != test.overflow {
+			t.Errorf("%q: have: %v", test.text, err)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		size      ByteSize
+		arg, text string
+	}{
+		{0, "0", "0"},
+		{1000, "1000", "1000"},
+		{Kilobyte, "1k", "1KB"},
+		{1536 * Kilobyte, "1536k", "1536KB"},
+		{MinIORate, "512k", "512KB"},
+		{10 * Megabyte, "10m", "10MB"},
+		{3 * Gigabyte, "3g", "3GB"},
+		{Gigabyte + 1, "1073741825", "1073741825"},
+	}
+	for _, test := range tests {
+		text, _ := test.size.MarshalText()
+		if test.size.String() != test.arg || string(text) != test.text {
+			t.Errorf("%d: have: %s %s, want: %s %s", test.size, test.size, text, test.arg, test.text)
+		}
+		if back, err := ParseByteSize(test.size.String()); back != test.size || err != nil {
+			t.Errorf("%s: parsed back to %d %v", test.size, back, err)
+		}
+	}
+}
+
+func TestByteSizeArithmetic(t *testing.T) {
+	if have, err := NewByteSize(10, unitflags.Megabytes); have != 10*Megabyte || err != nil {
+		t.Errorf("NewByteSize: have: %d %v", have, err)
+	}
+	if have, err := NewByteSize(7, 0); have != 7 || err != nil {
+		t.Errorf("NewByteSize bytes: have: %d %v", have, err)
+	}
+	if _, err := NewByteSize(1, unitflags.UnitFlags(8)); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("NewByteSize unknown unit: have: %v", err)
+	}
+	if _, err := NewByteSize(math.MaxInt64/1024+1, unitflags.Kilobytes); !errors.Is(err, ErrSizeOverflow) {
+		t.Errorf("NewByteSize overflow: have: %v", err)
+	}
+	if have, err := ByteSizeFromPair(types.Pair[int, unitflags.UnitFlags]{First: 512, Second: unitflags.Kilobytes}); have != MinIORate || err != nil {
+		t.Errorf("ByteSizeFromPair: have: %d %v", have, err)
+	}
+	overflows := []func() (ByteSize, error){
+		func() (ByteSize, error) { return ByteSize(math.MaxInt64).Add(1) },
+		func() (ByteSize, error) { return ByteSize(math.MinInt64).Add(-1) },
+		func() (ByteSize, error) { return ByteSize(math.MaxInt64/2 + 1).Mul(2) },
+		func() (ByteSize, error) { return ByteSize(math.MinInt64).Mul(-1) },
+		func() (ByteSize, error) { return ByteSize(-1).Mul(math.MinInt64) },
+	}
+	for i, f := range overflows {
+		if _, err := f(); !errors.Is(err, ErrSizeOverflow) {
+			t.Errorf("%d: have: %v", i, err)
+		}
+	}
+	if have, err := ByteSize(math.MaxInt64 - 1).Add(1); have != math.MaxInt64 || err != nil {
+		t.Errorf("Add: have: %d %v", have, err)
+	}
+	if have, err := ByteSize(-3).Mul(-4); have != 12 || err != nil {
+		t.Errorf("Mul: have: %d %v", have, err)
+	}
+}
+
+func TestByteSizeJSON(t *testing.T) {
+	var have struct{ A, B, C ByteSize }
+	if err := json.Unmarshal([]byte(`{"A":1048576,"B":"64mb","C":null}`), &have); err != nil {
+		t.Fatal(err)
+	}
+	if have.A != Megabyte || have.B != 64*Megabyte || have.C != 0 {
+		t.Errorf("have: %+v", have)
+	}
+	data, _ := json.Marshal(have)
+	if string(data) != `{"A":"1MB","B":"64MB","C":"0"}` {
+		t.Errorf("have: %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"A":-1}`), &have); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("have: %v", err)
+	}
+}
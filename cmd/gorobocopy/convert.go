@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/config"
+)
+
+// The pseudo format of robocopy command lines.
+const cmdFormat config.Format = "cmd"
+
+func (a *app) convert(ctx context.Context, args []string) int {
+	fs := a.flags("convert")
+	to := fs.String("to", "", "the output format: json, yaml, toml, rcj or cmd (default: from the -o extension)")
+	out := fs.String("o", "", "write to this file instead of stdout")
+	cmdline := fs.String("cmd", "", "convert this robocopy command line instead of a job file")
+	shell := fs.String("shell", string(gorobocopy.ShellCreateProcess), "the shell command lines are quoted for with -to cmd: createprocess, cmd or powershell")
+	if err := fs.Parse(args); err != nil {
+		return exitFatal
+	}
+	if (*cmdline == "") == (fs.NArg() != 1) {
+		fmt.Fprintln(a.stderr, "gorobocopy: give either one job file or -cmd")
+		fs.Usage()
+		return exitFatal
+	}
+
+	switch gorobocopy.Shell(*shell) {
+	case gorobocopy.ShellCreateProcess, gorobocopy.ShellCmd, gorobocopy.ShellPowerShell:
+	default:
+		fmt.Fprintf(a.stderr, "gorobocopy: unknown shell %q\n", *shell)
+		fs.Usage()
+		return exitFatal
+	}
+
+	format := config.Format(*to)
+	if format == "" && *out != "" {
+		var err error
+		if format, err = config.FormatOf(*out); err != nil {
+			fmt.Fprintln(a.stderr, err)
+			return exitFatal
+		}
+	}
+	if format == "" {
+		fmt.Fprintln(a.stderr, "gorobocopy: no output format given")
+		fs.Usage()
+		return exitFatal
+	}
+
+	var r *gorobocopy.Robocopy
+	var err error
+	if *cmdline != "" {
+		r, err = gorobocopy.ParseCommandString(*cmdline)
+	} else {
+		r, err = config.LoadJob(fs.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(a.stderr, err)
+		return exitFatal
+	}
+
+	var b bytes.Buffer
+	if format == cmdFormat {
+		fmt.Fprintln(&b, r.CommandLine(gorobocopy.Shell(*shell)))
+	} else if err := config.Write(&b, r, format); err != nil {
+		fmt.Fprintln(a.stderr, err)
+		return exitFatal
+	}
+	if *out == "" {
+		a.stdout.Write(b.Bytes())
+		return 0
+	}
+	if err := os.WriteFile(*out, b.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(a.stderr, err)
+		return exitFatal
+	}
+	return 0
+}
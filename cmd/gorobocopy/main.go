@@ -0,0 +1,197 @@
+// Command gorobocopy runs robocopy jobs defined in JSON, YAML, TOML or .RCJ job files.
+//
+// Usage:
+//
+//	gorobocopy <command> [flags] job...
+//
+// The commands are:
+//
+//	validate  check the jobs and print their robocopy command lines
+//	explain   describe every switch of the jobs, flagging the dangerous ones
+//	plan      list what the jobs would do without changing anything
+//	run       run the jobs one after the other, streaming their progress
+//	convert   convert a job between .RCJ, command line and config formats
+//
+// The format of a job file is given by its extension: .json, .yaml, .yml, .toml or .rcj.
+// Run and plan take -engine to copy with the pure-Go engine instead of robocopy and
+// -json to print a JSON object per job instead of text.
+//
+// The exit code of run is the combination of the robocopy exit codes of the jobs, so
+// values of 8 and above mean something failed. The other commands exit with 0 on
+// success. Usage errors, jobs that can't be loaded or started, jobs stopped or
+// skipped by an interrupt, and jobs ending with a code that isn't a robocopy exit
+// code, exit with 16.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/config"
+	"github.com/aggellos2001/go-robocopy/engine"
+)
+
+// Exit code for usage errors and jobs that can't be run, the same robocopy uses.
+const exitFatal = int(gorobocopy.FatalError)
+
+// The state shared by the commands.
+type app struct {
+	stdout, stderr io.Writer
+	// Used for every job when set, instead of robocopy or the engine.
+	executor gorobocopy.Executor
+}
+
+type command struct {
+	usage string
+	run   func(a *app, ctx context.Context, args []string) int
+}
+
+// Set by init, as the commands refer back to the table for their usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"validate": {"validate job...", (*app).validate},
+		"explain":  {"explain [-json] job...", (*app).explain},
+		"plan":     {"plan [-engine] [-json] job...", (*app).plan},
+		"run":      {"run [-engine] [-json] [-q] [-v] job...", (*app).run},
+		"convert":  {"convert -to json|yaml|toml|rcj|cmd [-shell createprocess|cmd|powershell] [-o file] (job | -cmd commandline)", (*app).convert},
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	a := &app{stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(a.main(ctx, os.Args[1:]))
+}
+
+func (a *app) main(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		a.usage()
+		return exitFatal
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		if args[0] != "help" && args[0] != "-h" && args[0] != "-help" {
+			fmt.Fprintf(a.stderr, "gorobocopy: unknown command %q\n", args[0])
+		}
+		a.usage()
+		return exitFatal
+	}
+	return cmd.run(a, ctx, args[1:])
+}
+
+func (a *app) usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(a.stderr, "usage:")
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  gorobocopy %s\n", commands[name].usage)
+	}
+}
+
+// Returns a flag set for the command that reports errors to stderr.
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "usage: gorobocopy %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Parses the flags of a command that takes job files. It returns false after
+// reporting the problem when the arguments are invalid.
+func (a *app) parseJobArgs(fs *flag.FlagSet, args []string) bool {
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(a.stderr, "gorobocopy: no job files given")
+		fs.Usage()
+		return false
+	}
+	return true
+}
+
+// Loads a job file, selecting the executor for it.
+func (a *app) load(path string, useEngine bool) (*gorobocopy.Robocopy, error) {
+	r, err := config.LoadJob(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case a.executor != nil:
+		r.SetExecutor(a.executor)
+	case useEngine:
+		r.SetExecutor(engine.Executor{})
+	}
+	return r, nil
+}
+
+func (a *app) validate(ctx context.Context, args []string) int {
+	fs := a.flags("validate")
+	if !a.parseJobArgs(fs, args) {
+		return exitFatal
+	}
+	code := 0
+	for _, path := range fs.Args() {
+		r, err := a.load(path, false)
+		if err == nil {
+			err = r.Validate()
+		}
+		if err != nil {
+			fmt.Fprintf(a.stderr, "%s: %v\n", path, strings.ReplaceAll(err.Error(), "\n", "\n\t"))
+			code = exitFatal
+			continue
+		}
+		fmt.Fprintf(a.stdout, "%s: %s\n", path, r.CommandLine(gorobocopy.ShellCreateProcess))
+	}
+	return code
+}
+
+func (a *app) explain(ctx context.Context, args []string) int {
+	fs := a.flags("explain")
+	asJSON := fs.Bool("json", false, "print the explanations of each job as a JSON array")
+	if !a.parseJobArgs(fs, args) {
+		return exitFatal
+	}
+	code := 0
+	for _, path := range fs.Args() {
+		r, err := a.load(path, false)
+		if err != nil {
+			fmt.Fprintln(a.stderr, err)
+			code = exitFatal
+			continue
+		}
+		if *asJSON {
+			json.NewEncoder(a.stdout).Encode(r.Explain())
+			continue
+		}
+		fmt.Fprintf(a.stdout, "%s: %s\n", path, r.CommandLine(gorobocopy.ShellCreateProcess))
+		for _, e := range r.Explain() {
+			fmt.Fprintf(a.stdout, "  %s\n", strings.ReplaceAll(e.String(), "\n", "\n  "))
+		}
+	}
+	return code
+}
+
+// Reports errors that aren't about the run itself, i.e. anything but an *ExitError.
+func runFailed(err error) bool {
+	var exitErr *gorobocopy.ExitError
+	return err != nil && !errors.As(err, &exitErr)
+}
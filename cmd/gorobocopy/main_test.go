@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/robocopytest"
+)
+
+// Runs the command line and returns the exit code, stdout and stderr.
+func runApp(executor gorobocopy.Executor, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	a := &app{stdout: &stdout, stderr: &stderr, executor: executor}
+	code := a.main(context.Background(), args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestValidate(t *testing.T) {
+	code, stdout, _ := runApp(nil, "validate", "testdata/job.yaml")
+	want := "testdata/job.yaml: robocopy C:\\data \"\\\\backup\\My Data\" *.* /copy:DAT /mir /r:3 /w:5\n"
+	if code != 0 || stdout != want {
+		t.Errorf("have: %d %q, want: 0 %q", code, stdout, want)
+	}
+
+	code, _, stderr := runApp(nil, "validate", "testdata/job.yaml", "testdata/invalid.json", "testdata/missing.toml")
+	if code != exitFatal || !strings.Contains(stderr, "testdata/invalid.json: ") || !strings.Contains(stderr, "missing.toml") {
+		t.Errorf("have: %d %q", code, stderr)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{{}, {"copy"}, {"run"}, {"run", "-x", "job.yaml"}, {"convert", "testdata/job.yaml"}} {
+		if code, _, stderr := runApp(nil, args...); code != exitFatal || !strings.Contains(stderr, "usage") {
+			t.Errorf("%q: have: %d %q", args, code, stderr)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	source, dest := filepath.Join(dir, "source"), filepath.Join(dir, "dest")
+	os.MkdirAll(filepath.Join(source, "sub"), 0o755)
+	os.WriteFile(filepath.Join(source, "a.txt"), []byte("hello"), 0o644)
+	os.WriteFile(filepath.Join(source, "sub", "b.txt"), []byte("world!"), 0o644)
+	job := filepath.Join(dir, "job.json")
+	data, _ := json.Marshal(map[string]any{"version": 1, "source": source, "destination": dest, "copy": map[string]any{"e": true}})
+	os.WriteFile(job, data, 0o644)
+
+	code, stdout, stderr := runApp(nil, "run", "-engine", "-json", "-v", job)
+	if code != int(gorobocopy.FilesCopied) {
+		t.Fatalf("exit code %d\n%s%s", code, stdout, stderr)
+	}
+	var result jobResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || strings.Join(result.Bits, ",") != "copied" || result.Summary == nil || result.Summary.Files.Copied != 2 || result.Summary.Bytes.Copied != 11 {
+		t.Errorf("result: %+v", result)
+	}
+	if !strings.HasPrefix(stderr, "robocopy "+source+" "+dest+" /e\n") || !strings.Contains(stderr, "New File") || !strings.Contains(stderr, "100.0% ") {
+		t.Errorf("progress:\n%s", stderr)
+	}
+	if b, err := os.ReadFile(filepath.Join(dest, "sub", "b.txt")); string(b) != "world!" {
+		t.Errorf("b.txt: %q, %v", b, err)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	fake := robocopytest.New(robocopytest.Response{ExitCode: gorobocopy.CopyFailures | gorobocopy.FilesCopied})
+	code, stdout, stderr := runApp(fake, "run", "-json", "testdata/job.yaml", "testdata/invalid.json")
+	if code != int(gorobocopy.CopyFailures|gorobocopy.FilesCopied|gorobocopy.FatalError) {
+		t.Errorf("exit code %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("output:\n%s", stdout)
+	}
+	var first, second jobResult
+	json.Unmarshal([]byte(lines[0]), &first)
+	json.Unmarshal([]byte(lines[1]), &second)
+	if first.Success || first.ExitCode != 9 || strings.Join(first.Bits, ",") != "copied,failures" {
+		t.Errorf("first: %+v", first)
+	}
+	if second.ExitCode != exitFatal || second.Error == "" {
+		t.Errorf("second: %+v", second)
+	}
+	if len(fake.Calls()) != 1 {
+		t.Errorf("calls: %q", fake.Calls())
+	}
+	if !strings.HasPrefix(stderr, "robocopy C:\\data \"\\\\backup\\My Data\" *.* /copy:DAT /mir /r:3 /w:5\n") {
+		t.Errorf("stderr: %q", stderr)
+	}
+	if _, _, stderr := runApp(fake, "run", "-q", "testdata/job.yaml"); strings.Contains(stderr, "robocopy") {
+		t.Errorf("quiet: %q", stderr)
+	}
+}
+
+func TestRunStopped(t *testing.T) {
+	fake := robocopytest.New(robocopytest.Response{Delay: time.Minute})
+	var stdout, stderr bytes.Buffer
+	a := &app{stdout: &stdout, stderr: &stderr, executor: fake}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if code := a.main(ctx, []string{"run", "-json", "testdata/job.yaml", "testdata/job.yaml"}); code != exitFatal {
+		t.Errorf("exit code %d\n%s", code, stdout.String())
+	}
+	dec := json.NewDecoder(&stdout)
+	var stopped, skipped jobResult
+	if err := dec.Decode(&stopped); err != nil || stopped.ExitCode != -1 || len(stopped.Bits) != 0 || stopped.Success || stopped.Skipped {
+		t.Errorf("stopped: %+v, %v", stopped, err)
+	}
+	if err := dec.Decode(&skipped); err != nil || !skipped.Skipped || len(skipped.Bits) != 0 || skipped.Success || skipped.Error == "" {
+		t.Errorf("skipped: %+v, %v", skipped, err)
+	}
+	if len(fake.Calls()) != 1 {
+		t.Errorf("%d runs", len(fake.Calls()))
+	}
+}
+
+// Stops the run as robocopy does when it is killed, with a success code.
+type killingExecutor struct{ cancel context.CancelFunc }
+
+func (e killingExecutor) Execute(ctx context.Context, cmd gorobocopy.Command) (gorobocopy.ExitCode, error) {
+	e.cancel()
+	return gorobocopy.FilesCopied, ctx.Err()
+}
+
+func TestRunStoppedWithSuccessCode(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := &app{stdout: &stdout, stderr: &stderr, executor: killingExecutor{cancel}}
+	if code := a.main(ctx, []string{"run", "testdata/job.yaml"}); code != exitFatal|int(gorobocopy.FilesCopied) {
+		t.Errorf("exit code %d\n%s", code, stdout.String())
+	}
+}
+
+func TestRunProcessStatus(t *testing.T) {
+	fake := robocopytest.New(robocopytest.Response{ExitCode: 0xC000013A})
+	var stdout, stderr bytes.Buffer
+	a := &app{stdout: &stdout, stderr: &stderr, executor: fake}
+	if code := a.main(context.Background(), []string{"run", "-json", "testdata/job.yaml"}); code != exitFatal {
+		t.Errorf("exit code %d\n%s", code, stdout.String())
+	}
+	var result jobResult
+	if err := json.NewDecoder(&stdout).Decode(&result); err != nil || len(result.Bits) != 0 || result.Success {
+		t.Errorf("%+v, %v", result, err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	cmdline := `robocopy C:\data "\\backup\My Data" *.* /copy:DAT /mir /r:3 /w:5`
+	code, yaml, stderr := runApp(nil, "convert", "-to", "yaml", "-cmd", cmdline)
+	if code != 0 {
+		t.Fatal(stderr)
+	}
+	path := filepath.Join(t.TempDir(), "job.yaml")
+	os.WriteFile(path, []byte(yaml), 0o644)
+	rcj := filepath.Join(t.TempDir(), "job.rcj")
+	if code, _, stderr := runApp(nil, "convert", "-o", rcj, path); code != 0 {
+		t.Fatal(stderr)
+	}
+	code, stdout, stderr := runApp(nil, "convert", "-to", "cmd", rcj)
+	if code != 0 || stdout != cmdline+"\n" {
+		t.Errorf("have: %d %q %s\nwant: %q", code, stdout, stderr, cmdline)
+	}
+}
+
+func TestConvertShell(t *testing.T) {
+	cmdline := `robocopy "C:\My Data\\" D:\backup *.* /mir`
+	tests := map[string]string{
+		"cmd":        `robocopy "C:\My Data\\" D:\backup *.* /mir`,
+		"powershell": `robocopy 'C:\My Data' D:\backup '*.*' /mir`,
+	}
+	for shell, want := range tests {
+		code, stdout, stderr := runApp(nil, "convert", "-to", "cmd", "-shell", shell, "-cmd", cmdline)
+		if code != 0 || stdout != want+"\n" {
+			t.Errorf("%s: have: %d %q %s\nwant: %q", shell, code, stdout, stderr, want)
+		}
+	}
+	if code, _, _ := runApp(nil, "convert", "-to", "cmd", "-shell", "bash", "-cmd", cmdline); code != exitFatal {
+		t.Errorf("bash: have: %d", code)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	code, stdout, stderr := runApp(nil, "explain", "testdata/job.yaml")
+	if code != 0 {
+		t.Fatal(stderr)
+	}
+	for _, want := range []string{"  /mir\tMirrors a directory tree", "\tWARNING: Deletes files", "\tNote: Implies /e and /purge.", "  /w:5\tSpecifies the wait time"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("%q not in:\n%s", want, stdout)
+		}
+	}
+
+	code, stdout, _ = runApp(nil, "explain", "-json", "testdata/job.yaml")
+	var explanations []gorobocopy.Explanation
+	if err := json.Unmarshal([]byte(stdout), &explanations); code != 0 || err != nil || len(explanations) != 7 {
+		t.Errorf("have: %d %v %+v", code, err, explanations)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+// The result of a job as printed by run -json.
+type jobResult struct {
+	Job      string          `json:"job"`
+	ExitCode int             `json:"exitCode"`
+	Bits     []string        `json:"bits"`     // The names of the exit code bits that are set.
+	Success  bool            `json:"success"`  // The exit code is below 8.
+	Duration float64         `json:"duration"` // In seconds.
+	Summary  *output.Summary `json:"summary,omitempty"`
+	Skipped  bool            `json:"skipped,omitempty"` // Not run, as the command was canceled.
+	Error    string          `json:"error,omitempty"`
+}
+
+// Returns the names of the bits set in the exit code. Codes outside of 0 to 31, such
+// as -1 for a stopped run or a process status, have no bits.
+func exitBits(code gorobocopy.ExitCode) []string {
+	bits := []string{}
+	if code < 0 || code > 31 {
+		return bits
+	}
+	for _, bit := range []struct {
+		code gorobocopy.ExitCode
+		name string
+	}{
+		{gorobocopy.FilesCopied, "copied"},
+		{gorobocopy.ExtrasDetected, "extras"},
+		{gorobocopy.MismatchesDetected, "mismatches"},
+		{gorobocopy.CopyFailures, "failures"},
+		{gorobocopy.FatalError, "fatal"},
+	} {
+		if code&bit.code != 0 {
+			bits = append(bits, bit.name)
+		}
+	}
+	return bits
+}
+
+func (a *app) run(ctx context.Context, args []string) int {
+	fs := a.flags("run")
+	useEngine := fs.Bool("engine", false, "copy with the pure-Go engine instead of robocopy")
+	asJSON := fs.Bool("json", false, "print the result of each job as a JSON object")
+	quiet := fs.Bool("q", false, "don't print progress")
+	verbose := fs.Bool("v", false, "print every file that is copied or skipped")
+	if !a.parseJobArgs(fs, args) {
+		return exitFatal
+	}
+	code := 0
+	for _, path := range fs.Args() {
+		var result *jobResult
+		if err := ctx.Err(); err != nil {
+			// Canceled jobs are still reported, so every job file is accounted for.
+			result = &jobResult{Job: path, ExitCode: -1, Bits: []string{}, Skipped: true, Error: "skipped: " + err.Error()}
+		} else {
+			result = a.runJob(ctx, path, *useEngine, *quiet, *verbose)
+		}
+		// Other codes, such as a process status after Ctrl+Break, aren't robocopy bits.
+		if result.ExitCode >= 0 && result.ExitCode <= 31 {
+			code |= result.ExitCode
+		} else {
+			code |= exitFatal
+		}
+		// A stopped run can end with success bits, or -1 which has none.
+		if result.Error != "" {
+			code |= exitFatal
+		}
+		if *asJSON {
+			json.NewEncoder(a.stdout).Encode(result)
+			continue
+		}
+		status := fmt.Sprintf("exit code %d %v", result.ExitCode, result.Bits)
+		if result.Error != "" {
+			status = result.Error
+		}
+		fmt.Fprintf(a.stdout, "%s: %s\n", path, status)
+		if s := result.Summary; s != nil {
+			fmt.Fprintf(a.stdout, "  dirs: %d copied, %d skipped, %d failed, %d extras\n", s.Dirs.Copied, s.Dirs.Skipped, s.Dirs.Failed, s.Dirs.Extras)
+			fmt.Fprintf(a.stdout, "  files: %d copied, %d skipped, %d failed, %d extras\n", s.Files.Copied, s.Files.Skipped, s.Files.Failed, s.Files.Extras)
+			fmt.Fprintf(a.stdout, "  bytes: %d copied in %.1fs\n", s.Bytes.Copied, result.Duration)
+		}
+	}
+	return code
+}
+
+// Runs one job, printing its robocopy command line and its progress to stderr unless
+// quiet.
+func (a *app) runJob(ctx context.Context, path string, useEngine, quiet, verbose bool) *jobResult {
+	failed := func(err error) *jobResult {
+		return &jobResult{Job: path, ExitCode: exitFatal, Bits: exitBits(gorobocopy.FatalError), Error: err.Error()}
+	}
+	r, err := a.load(path, useEngine)
+	if err != nil {
+		return failed(err)
+	}
+	if !quiet {
+		fmt.Fprintln(a.stderr, r.CommandLine(gorobocopy.ShellCreateProcess))
+	}
+	job, err := r.Start(ctx, gorobocopy.StreamOptions{})
+	if err != nil {
+		return failed(err)
+	}
+	for event := range job.Events() {
+		if event.Output != nil {
+			a.printEvent(event, quiet, verbose)
+		}
+	}
+	result, err := job.Wait()
+	if result == nil {
+		return failed(err)
+	}
+	jr := &jobResult{
+		Job:      path,
+		ExitCode: int(result.ExitCode),
+		Bits:     exitBits(result.ExitCode),
+		Success:  result.ExitCode.IsSuccess(),
+		Duration: result.Duration.Seconds(),
+		Summary:  result.Summary,
+	}
+	if runFailed(err) {
+		jr.Success, jr.Error = false, err.Error()
+	}
+	return jr
+}
+
+// Prints the progress of a run to stderr. Errors are printed even when quiet.
+func (a *app) printEvent(event gorobocopy.Event, quiet, verbose bool) {
+	switch e := event.Output.(type) {
+	case output.ErrorEvent:
+		if e.Path != "" {
+			fmt.Fprintf(a.stderr, "error: %s %s: %s\n", e.Action, e.Path, e.Message)
+		} else {
+			fmt.Fprintf(a.stderr, "error: %s\n", e.Message)
+		}
+	case output.HeaderEvent:
+		if !quiet {
+			fmt.Fprintf(a.stderr, "%s -> %s\n", e.Source, e.Destination)
+		}
+	case output.FileEvent:
+		if verbose {
+			fmt.Fprintf(a.stderr, "%-12s %s\n", e.Class, e.Path)
+		}
+	case output.ProgressEvent:
+		if quiet {
+			return
+		}
+		p := event.Progress
+		fmt.Fprintf(a.stderr, "\r%5.1f%% %s (%d of %d bytes, %.0f bytes/s)", e.Percent, e.Path, p.BytesDone, p.BytesTotal, p.BytesPerSecond)
+		if e.Percent >= 100 {
+			fmt.Fprintln(a.stderr)
+		}
+	case output.RetryEvent:
+		if !quiet {
+			fmt.Fprintf(a.stderr, "retrying in %s\n", e.Wait)
+		}
+	}
+}
+
+func (a *app) plan(ctx context.Context, args []string) int {
+	fs := a.flags("plan")
+	useEngine := fs.Bool("engine", false, "walk the trees with the pure-Go engine instead of robocopy")
+	asJSON := fs.Bool("json", false, "print each plan as a JSON object")
+	if !a.parseJobArgs(fs, args) {
+		return exitFatal
+	}
+	code := 0
+	for _, path := range fs.Args() {
+		r, err := a.load(path, *useEngine)
+		if err != nil {
+			fmt.Fprintln(a.stderr, err)
+			code = exitFatal
+			continue
+		}
+		plan, err := r.Plan(ctx)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "%s: %v\n", path, err)
+			code = exitFatal
+			continue
+		}
+		if *asJSON {
+			json.NewEncoder(a.stdout).Encode(plan)
+		} else {
+			fmt.Fprint(a.stdout, plan)
+		}
+	}
+	return code
+}
@@ -0,0 +1,129 @@
+package gorobocopy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Shell is the interpreter a command line is written for, which decides how its
+// arguments are quoted and escaped.
+type Shell string
+
+const (
+	// The raw command line given to CreateProcess, as in Task Scheduler actions. Robocopy
+	// splits it the way CommandLineToArgvW does.
+	ShellCreateProcess Shell = "createprocess"
+	// A line of a .bat or .cmd batch file. Delayed expansion (!var!) is assumed to be off.
+	ShellCmd Shell = "cmd"
+	// A line of a PowerShell script, for Windows PowerShell and PowerShell 7 alike.
+	ShellPowerShell Shell = "powershell"
+)
+
+// The characters cmd.exe gives a meaning to outside of double quotes.
+const cmdSpecials = "&|<>^()"
+
+// CommandLine returns the robocopy command line for the shell, starting with the
+// program name, with every argument quoted and escaped so that robocopy receives the
+// arguments of GetCommandArgs unchanged, but for the trailing backslashes PowerShell
+// drops (see Shell.Quote). Other shells get the CreateProcess rules.
+func (r *Robocopy) CommandLine(shell Shell) string {
+	line := []string{"robocopy"}
+	for _, arg := range r.GetCommandArgs() {
+		line = append(line, shell.Quote(arg))
+	}
+	return strings.Join(line, " ")
+}
+
+// Quote returns the argument as it must be written on a command line for the shell.
+//
+// For PowerShell the trailing backslashes of an argument holding spaces or tabs are
+// removed, so robocopy receives C:\My Data for C:\My Data\. PowerShell before 7.3 puts
+// such an argument in double quotes without escaping it, and no escape reads the same in
+// the older and newer versions. The directory is the same, as the roots of drives, the
+// only ones needing the backslash, hold no spaces. Arguments without spaces are kept.
+func (shell Shell) Quote(arg string) string {
+	switch shell {
+	case ShellCmd:
+		return quoteCmd(arg)
+	case ShellPowerShell:
+		return quotePowerShell(arg)
+	default:
+		return quoteCreateProcess(arg, " \t\n\v\"")
+	}
+}
+
+// Quotes an argument containing spaces or any of the special characters, doubling the
+// backslashes that would otherwise escape a quote. A quoted C:\dir\ in particular is
+// written as "C:\dir\\", as robocopy would read "C:\dir\" as C:\dir".
+func quoteCreateProcess(arg, specials string) string {
+	if arg != "" && !strings.ContainsAny(arg, specials) {
+		return arg
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for _, c := range arg {
+		switch c {
+		case '\\':
+			backslashes++
+		case '"':
+			b.WriteString(strings.Repeat(`\`, backslashes+1))
+			backslashes = 0
+		default:
+			backslashes = 0
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(strings.Repeat(`\`, backslashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Quotes an argument for a batch file. Arguments holding characters special to cmd.exe
+// are quoted, and the ones cmd.exe would still see outside of quotes, because it takes
+// an escaped \" for a closing quote, are escaped with a caret. Percent signs are doubled
+// wherever they are, as batch files expand variables inside quotes too.
+func quoteCmd(arg string) string {
+	var b strings.Builder
+	inQuotes := false
+	for _, c := range quoteCreateProcess(arg, " \t\n\v\""+cmdSpecials) {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == '%':
+			b.WriteByte('%')
+		case !inQuotes && strings.ContainsRune(cmdSpecials, c):
+			b.WriteByte('^')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Quotes an argument for PowerShell in single quotes, where only the single quotes,
+// typographic ones included, need doubling. The trailing backslashes of arguments with
+// spaces are dropped, as described on Shell.Quote.
+func quotePowerShell(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(c rune) bool { return !powerShellBare(c) }) < 0 {
+		return arg
+	}
+	if strings.ContainsAny(arg, " \t") {
+		arg = strings.TrimRight(arg, `\`)
+	}
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, c := range arg {
+		if strings.ContainsRune("'‘’‚‛", c) {
+			b.WriteRune(c)
+		}
+		b.WriteRune(c)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// Reports whether the character needs no quotes in a PowerShell argument. Wildcards are
+// quoted too, as PowerShell expands them for native commands on other systems.
+func powerShellBare(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune(`\/:.-_+=`, c)
+}
@@ -0,0 +1,161 @@
+package gorobocopy
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		arg                      string
+		createProcess, cmd, pwsh string
+	}{
+		{`C:\data`, `C:\data`, `C:\data`, `C:\data`},
+		{`C:\data\`, `C:\data\`, `C:\data\`, `C:\data\`},
+		{`C:\My Data`, `"C:\My Data"`, `"C:\My Data"`, `'C:\My Data'`},
+		{`C:\My Data\`, `"C:\My Data\\"`, `"C:\My Data\\"`, `'C:\My Data'`},
+		{`\\server\my share\\`, `"\\server\my share\\\\"`, `"\\server\my share\\\\"`, `'\\server\my share'`},
+		{`\\server\share x`, `"\\server\share x"`, `"\\server\share x"`, `'\\server\share x'`},
+		{``, `""`, `""`, `''`},
+		{`say "hi"`, `"say \"hi\""`, `"say \"hi\""`, `'say "hi"'`},
+		{`a\"b c`, `"a\\\"b c"`, `"a\\\"b c"`, `'a\"b c'`},
+		{`C:\100%`, `C:\100%`, `C:\100%%`, `'C:\100%'`},
+		{`C:\%TEMP%\x y`, `"C:\%TEMP%\x y"`, `"C:\%%TEMP%%\x y"`, `'C:\%TEMP%\x y'`},
+		{`R&D`, `R&D`, `"R&D"`, `'R&D'`},
+		{`a^b`, `a^b`, `"a^b"`, `'a^b'`},
+		{`x(1)`, `x(1)`, `"x(1)"`, `'x(1)'`},
+		{`say "a&b"`, `"say \"a&b\""`, `"say \"a^&b\""`, `'say "a&b"'`},
+		{`$env:TEMP`, `$env:TEMP`, `$env:TEMP`, `'$env:TEMP'`},
+		{"a`b", "a`b", "a`b", "'a`b'"},
+		{`it's`, `it's`, `it's`, `'it''s'`},
+		{`it’s`, `it’s`, `it’s`, `'it’’s'`},
+		{`*.tmp`, `*.tmp`, `*.tmp`, `'*.tmp'`},
+		{`/copy:DAT`, `/copy:DAT`, `/copy:DAT`, `/copy:DAT`},
+		{`/log:C:\my logs\run.log`, `"/log:C:\my logs\run.log"`, `"/log:C:\my logs\run.log"`, `'/log:C:\my logs\run.log'`},
+		{`Ünïcödé`, `Ünïcödé`, `Ünïcödé`, `Ünïcödé`},
+	}
+	for _, test := range tests {
+		for shell, want := range map[Shell]string{ShellCreateProcess: test.createProcess, ShellCmd: test.cmd, ShellPowerShell: test.pwsh} {
+			if have := shell.Quote(test.arg); have != want {
+				t.Errorf("%s %s: have: %s, want: %s", shell, test.arg, have, want)
+			}
+		}
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	r := NewRobocopy(`C:\My Data\`, `\\backup\data`, "*.*")
+	r.SetCopyOptions(&CopyOptions{Mir: true})
+	r.SetLoggingOptions(&LoggingOptions{Log: `C:\logs\R&D 100%.log`})
+	tests := map[Shell]string{
+		ShellCreateProcess: `robocopy "C:\My Data\\" \\backup\data *.* /mir "/log:C:\logs\R&D 100%.log"`,
+		ShellCmd:           `robocopy "C:\My Data\\" \\backup\data *.* /mir "/log:C:\logs\R&D 100%%.log"`,
+		ShellPowerShell:    `robocopy 'C:\My Data' \\backup\data '*.*' /mir '/log:C:\logs\R&D 100%.log'`,
+		"":                 `robocopy "C:\My Data\\" \\backup\data *.* /mir "/log:C:\logs\R&D 100%.log"`,
+	}
+	for shell, want := range tests {
+		if have := r.CommandLine(shell); have != want {
+			t.Errorf("%q: have: %s\nwant: %s", shell, have, want)
+		}
+	}
+}
+
+// Checks that robocopy gets back the arguments from the command lines of every shell,
+// with the shells emulated the way they handle quotes and escapes.
+func TestQuoteRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(5, 6))
+	const alphabet = "ab \t\\\\\"%^&|<>()!'’$`*?;,@#{}"
+	for i := 0; i < 5000; i++ {
+		var b strings.Builder
+		for n := rnd.IntN(8); n > 0; n-- {
+			b.WriteRune([]rune(alphabet)[rnd.IntN(len([]rune(alphabet)))])
+		}
+		arg := b.String()
+		if have := splitCommandLine(ShellCreateProcess.Quote(arg)); !slices.Equal(have, []string{arg}) {
+			t.Fatalf("createprocess %q: have: %q", arg, have)
+		}
+		if have := splitCommandLine(runCmd(ShellCmd.Quote(arg))); !slices.Equal(have, []string{arg}) {
+			t.Fatalf("cmd %q: quoted as %s, have: %q", arg, ShellCmd.Quote(arg), have)
+		}
+		want := arg
+		if strings.ContainsAny(arg, " \t") {
+			want = strings.TrimRight(arg, `\`)
+		}
+		if strings.Contains(arg, `"`) {
+			continue // PowerShell before 7.3 drops the double quotes of arguments
+		}
+		for _, legacy := range []bool{false, true} {
+			if have := splitCommandLine(runPowerShell(ShellPowerShell.Quote(arg), legacy)); !slices.Equal(have, []string{want}) && !(want == "" && legacy) {
+				t.Fatalf("powershell %q, legacy %v: quoted as %s, have: %q", arg, legacy, ShellPowerShell.Quote(arg), have)
+			}
+		}
+	}
+}
+
+func TestQuotePowerShellTrailingBackslashes(t *testing.T) {
+	tests := []struct {
+		arg, want string // want is what robocopy receives
+	}{
+		{`C:\My Data\`, `C:\My Data`},
+		{`C:\My Data\\\`, `C:\My Data`},
+		{"C:\\data\tdir\\", "C:\\data\tdir"},
+		{`\\server\my share\`, `\\server\my share`},
+		{`/log:C:\my logs\`, `/log:C:\my logs`},
+		// Without spaces the backslashes are kept.
+		{`C:\`, `C:\`},
+		{`C:\data\`, `C:\data\`},
+		{`C:\it's\`, `C:\it's\`},
+	}
+	for _, test := range tests {
+		for _, legacy := range []bool{false, true} {
+			if have := splitCommandLine(runPowerShell(ShellPowerShell.Quote(test.arg), legacy)); !slices.Equal(have, []string{test.want}) {
+				t.Errorf("%q, legacy %v: quoted as %s, have: %q, want: %q", test.arg, legacy, ShellPowerShell.Quote(test.arg), have, test.want)
+			}
+		}
+	}
+}
+
+// Returns the command line cmd.exe runs for a line of a batch file: it collapses %%
+// everywhere and drops the carets escaping the character that follows outside quotes.
+func runCmd(line string) string {
+	var b strings.Builder
+	inQuotes := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '%' && i+1 < len(line) && line[i+1] == '%':
+			i++
+		case c == '^' && !inQuotes && i+1 < len(line):
+			i++
+		case c == '"':
+			inQuotes = !inQuotes
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
+
+// Returns the command line PowerShell starts a native command with for a single
+// argument, bare or in single quotes. Since 7.3 PowerShell quotes it for CreateProcess,
+// before that it only put it in double quotes if it held spaces.
+func runPowerShell(arg string, legacy bool) string {
+	if strings.HasPrefix(arg, "'") {
+		var b strings.Builder
+		runes := []rune(arg[1 : len(arg)-1])
+		for i := 0; i < len(runes); i++ {
+			if strings.ContainsRune("'‘’‚‛", runes[i]) {
+				i++
+			}
+			b.WriteRune(runes[i])
+		}
+		arg = b.String()
+	}
+	if !legacy {
+		return ShellCreateProcess.Quote(arg)
+	}
+	if strings.ContainsAny(arg, " \t") {
+		return `"` + arg + `"`
+	}
+	return arg
+}
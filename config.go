@@ -0,0 +1,242 @@
+package gorobocopy
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigVersion is the version of the configuration schema written by this package.
+const ConfigVersion = 1
+
+// The configuration has a version this package doesn't know how to read.
+var ErrUnsupportedVersion = errors.New("unsupported config version")
+
+// Config is the form a Robocopy instance takes in JSON, YAML and TOML documents. Flag
+// sets are written as letters (copy: DATSOU), sizes with their unit (iorate: 10MB) and
+// the retry wait as a duration (w: 30s). Robocopy marshals to and from this form, so
+// it can be embedded in other documents too.
+type Config struct {
+	Version     int                        `json:"version" yaml:"version" toml:"version"`
+	Source      string                     `json:"source" yaml:"source" toml:"source"`
+	Destination string                     `json:"destination" yaml:"destination" toml:"destination"`
+	Files       []string                   `json:"files,omitempty" yaml:"files,omitempty" toml:"files,omitempty"`
+	Copy        *CopyOptions               `json:"copy,omitempty" yaml:"copy,omitempty" toml:"copy,omitempty"`
+	Throttling  *CopyFileThrottlingOptions `json:"throttling,omitempty" yaml:"throttling,omitempty" toml:"throttling,omitempty"`
+	Selection   *FileSelectionOptions      `json:"selection,omitempty" yaml:"selection,omitempty" toml:"selection,omitempty"`
+	Retry       *RetryOptions              `json:"retry,omitempty" yaml:"retry,omitempty" toml:"retry,omitempty"`
+	Logging     *LoggingOptions            `json:"logging,omitempty" yaml:"logging,omitempty" toml:"logging,omitempty"`
+	Job         *JobOptions                `json:"job,omitempty" yaml:"job,omitempty" toml:"job,omitempty"`
+}
+
+// Returns the configuration of r. The option structs are shared with r.
+func (r *Robocopy) Config() *Config {
+	return &Config{
+		Version:     ConfigVersion,
+		Source:      r.source,
+		Destination: r.destination,
+		Files:       r.files,
+		Copy:        r.copyOpt,
+		Throttling:  r.throttlingOpt,
+		Selection:   r.fileslOpt,
+		Retry:       r.retryOpt,
+		Logging:     r.loggingOpt,
+		Job:         r.jobOpt,
+	}
+}
+
+// NewRobocopyFromConfig returns a new robocopy instance configured by c. It fails with
+// ErrUnsupportedVersion if c has a version other than ConfigVersion.
+func NewRobocopyFromConfig(c *Config) (*Robocopy, error) {
+	r := &Robocopy{}
+	if err := r.setConfig(c); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Robocopy) setConfig(c *Config) error {
+	if c.Version != ConfigVersion {
+		return fmt.Errorf("gorobocopy: %w %d, expected %d", ErrUnsupportedVersion, c.Version, ConfigVersion)
+	}
+	r.source, r.destination = c.Source, c.Destination
+	r.SetFiles(c.Files...)
+	r.copyOpt, r.throttlingOpt, r.fileslOpt = c.Copy, c.Throttling, c.Selection
+	r.retryOpt, r.loggingOpt, r.jobOpt = c.Retry, c.Logging, c.Job
+	return nil
+}
+
+func (r *Robocopy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Config())
+}
+
+// UnmarshalJSON rejects unknown fields, so that misspelled options don't go unnoticed.
+func (r *Robocopy) UnmarshalJSON(data []byte) error {
+	var c Config
+	if err := decodeJSON(data, &c); err != nil {
+		return err
+	}
+	return r.setConfig(&c)
+}
+
+func (r *Robocopy) MarshalYAML() (any, error) {
+	return r.Config(), nil
+}
+
+func (r *Robocopy) UnmarshalYAML(unmarshal func(any) error) error {
+	var c Config
+	if err := unmarshal(&c); err != nil {
+		return err
+	}
+	return r.setConfig(&c)
+}
+
+// UnmarshalTOML takes the decoded TOML document. TOML encoders should be given the
+// Config of the instance instead, as the fields of Robocopy are not exported.
+func (r *Robocopy) UnmarshalTOML(data any) error {
+	return unmarshalTOML(data, r)
+}
+
+// MarshalJSON leaves out the run hours if they are not set, as encoding/json doesn't
+// omit empty structs.
+func (c CopyOptions) MarshalJSON() ([]byte, error) {
+	type options CopyOptions
+	var rh *RunWindow
+	if !c.Rh.IsZero() {
+		rh = &c.Rh
+	}
+	return json.Marshal(struct {
+		options
+		Rh *RunWindow `json:"rh,omitempty"`
+	}{options(c), rh})
+}
+
+// MarshalJSON leaves out the age filters that are not set, as encoding/json doesn't
+// omit empty structs.
+func (fso FileSelectionOptions) MarshalJSON() ([]byte, error) {
+	type options FileSelectionOptions
+	return json.Marshal(struct {
+		options
+		Maxage *AgeFilter `json:"maxage,omitempty"`
+		Minage *AgeFilter `json:"minage,omitempty"`
+		Maxlad *AgeFilter `json:"maxlad,omitempty"`
+		Minlad *AgeFilter `json:"minlad,omitempty"`
+	}{options(fso), setAge(fso.Maxage), setAge(fso.Minage), setAge(fso.Maxlad), setAge(fso.Minlad)})
+}
+
+// Returns a pointer to the filter, or nil if it is not set.
+func setAge(a AgeFilter) *AgeFilter {
+	if a.IsZero() {
+		return nil
+	}
+	return &a
+}
+
+// The form of RetryOptions in configuration documents.
+type retryConfig struct {
+	R        int      `json:"r,omitempty" yaml:"r,omitempty" toml:"r,omitempty"`
+	W        string   `json:"w,omitempty" yaml:"w,omitempty" toml:"w,omitempty"`
+	Reg      bool     `json:"reg,omitempty" yaml:"reg,omitempty" toml:"reg,omitempty"`
+	Tbd      bool     `json:"tbd,omitempty" yaml:"tbd,omitempty" toml:"tbd,omitempty"`
+	Lfsm     bool     `json:"lfsm,omitempty" yaml:"lfsm,omitempty" toml:"lfsm,omitempty"`
+	LfsmSize ByteSize `json:"lfsmsize,omitempty" yaml:"lfsmsize,omitempty" toml:"lfsmsize,omitzero"`
+}
+
+func (ropt RetryOptions) config() retryConfig {
+	c := retryConfig{R: ropt.R, Reg: ropt.Reg, Tbd: ropt.Tbd, Lfsm: ropt.Lfsm, LfsmSize: ropt.LfsmSize}
+	if ropt.W != 0 {
+		c.W = ropt.W.String()
+	}
+	return c
+}
+
+func (ropt *RetryOptions) setConfig(c retryConfig) error {
+	opts := RetryOptions{R: c.R, Reg: c.Reg, Tbd: c.Tbd, Lfsm: c.Lfsm, LfsmSize: c.LfsmSize}
+	if c.W != "" {
+		wait, err := time.ParseDuration(c.W)
+		if err != nil || wait%time.Second != 0 {
+			return fmt.Errorf("gorobocopy: invalid w %q, use a whole number of seconds such as 30s", c.W)
+		}
+		opts.W = wait
+	}
+	*ropt = opts
+	return nil
+}
+
+func (ropt RetryOptions) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ropt.config())
+}
+
+func (ropt *RetryOptions) UnmarshalJSON(data []byte) error {
+	var c retryConfig
+	if err := decodeJSON(data, &c); err != nil {
+		return err
+	}
+	return ropt.setConfig(c)
+}
+
+func (ropt RetryOptions) MarshalYAML() (any, error) {
+	return ropt.config(), nil
+}
+
+func (ropt *RetryOptions) UnmarshalYAML(unmarshal func(any) error) error {
+	var c retryConfig
+	if err := unmarshal(&c); err != nil {
+		return err
+	}
+	return ropt.setConfig(c)
+}
+
+func (ropt RetryOptions) MarshalTOML() ([]byte, error) {
+	return inlineTable(ropt.config()), nil
+}
+
+func (ropt *RetryOptions) UnmarshalTOML(data any) error {
+	return unmarshalTOML(data, ropt)
+}
+
+// Decodes JSON rejecting unknown fields.
+func decodeJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// TOML decoders hand over the decoded document, which has the same shape as the JSON one.
+func unmarshalTOML(data any, v json.Unmarshaler) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return v.UnmarshalJSON(b)
+}
+
+// Returns the non-zero fields of a struct of strings, integers, booleans and text
+// marshalers as a TOML inline table, using the toml tags as keys.
+func inlineTable(v any) []byte {
+	var parts []string
+	rv := reflect.ValueOf(v)
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		key, _, _ := strings.Cut(rv.Type().Field(i).Tag.Get("toml"), ",")
+		switch value := field.Interface().(type) {
+		case encoding.TextMarshaler:
+			text, _ := value.MarshalText()
+			parts = append(parts, key+" = "+strconv.Quote(string(text)))
+		case string:
+			parts = append(parts, key+" = "+strconv.Quote(value))
+		default:
+			parts = append(parts, fmt.Sprintf("%s = %v", key, field.Interface()))
+		}
+	}
+	return []byte("{" + strings.Join(parts, ", ") + "}")
+}
@@ -0,0 +1,143 @@
+// Package config reads and writes robocopy jobs as JSON, YAML and TOML documents, in
+// the form described by gorobocopy.Config:
+//
+//	version: 1
+//	source: C:\data
+//	destination: \\backup\data
+//	copy:
+//	  mir: true
+//	  copy: DATSOU
+//	retry:
+//	  r: 3
+//	  w: 30s
+//
+// Unknown fields are rejected so that misspelled options don't go unnoticed.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/jobfile"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the encoding of a job document.
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	TOML Format = "toml"
+	RCJ  Format = "rcj" // Robocopy job file, see package jobfile.
+)
+
+// The file extension doesn't name a known format.
+var ErrUnknownFormat = errors.New("unknown job format")
+
+// Returns the format of a file from its extension: .json, .yaml, .yml, .toml or .rcj.
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, nil
+	case ".yaml", ".yml":
+		return YAML, nil
+	case ".toml":
+		return TOML, nil
+	case ".rcj":
+		return RCJ, nil
+	}
+	return "", fmt.Errorf("config: %w: %q", ErrUnknownFormat, path)
+}
+
+// Parse reads a job document in the given format.
+func Parse(r io.Reader, format Format) (*gorobocopy.Robocopy, error) {
+	rc := &gorobocopy.Robocopy{}
+	switch format {
+	case JSON:
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(rc); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	case YAML:
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(rc); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	case TOML:
+		// The TOML decoder hands the whole document to Robocopy.UnmarshalTOML, which
+		// rejects unknown fields.
+		if _, err := toml.NewDecoder(r).Decode(rc); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	case RCJ:
+		return jobfile.Parse(r)
+	default:
+		return nil, fmt.Errorf("config: %w: %q", ErrUnknownFormat, format)
+	}
+	return rc, nil
+}
+
+// Write writes a job document in the given format.
+func Write(w io.Writer, rc *gorobocopy.Robocopy, format Format) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rc)
+	case YAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(rc); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case TOML:
+		encoder := toml.NewEncoder(w)
+		encoder.Indent = ""
+		return encoder.Encode(rc.Config())
+	case RCJ:
+		return jobfile.Write(w, rc)
+	}
+	return fmt.Errorf("config: %w: %q", ErrUnknownFormat, format)
+}
+
+// LoadJob reads the job stored at path, in the format given by its extension.
+func LoadJob(path string) (*gorobocopy.Robocopy, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := Parse(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rc, nil
+}
+
+// SaveJob writes a job to path, in the format given by its extension.
+func SaveJob(path string, rc *gorobocopy.Robocopy) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := Write(&b, rc, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
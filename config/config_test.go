@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+)
+
+// The job described by the files in testdata.
+func testJob() *gorobocopy.Robocopy {
+	r := gorobocopy.NewRobocopy("C:\\data", "\\\\backup\\data", "")
+	r.SetCopyOptions(&gorobocopy.CopyOptions{
+		Copy: copyflags.D | copyflags.A | copyflags.T | copyflags.S | copyflags.O | copyflags.U,
+		Mir:  true,
+		Mt:   8,
+	})
+	r.SetThrottlingOptions(&gorobocopy.CopyFileThrottlingOptions{
+		Iorate: 10 * gorobocopy.Megabyte,
+	})
+	r.SetFileSelectionOptions(&gorobocopy.FileSelectionOptions{
+		Xf: []string{"*.tmp", "*.bak"},
+		Xd: []string{"node_modules"},
+	})
+	r.SetRetryOptions(&gorobocopy.RetryOptions{R: 3, W: 30 * time.Second})
+	r.SetLoggingOptions(&gorobocopy.LoggingOptions{Log: "C:\\logs\\backup.log", Np: true})
+	return r
+}
+
+func TestLoadJob(t *testing.T) {
+	for _, name := range []string{"job.json", "job.yaml", "job.toml"} {
+		have, err := LoadJob(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if want := testJob(); !reflect.DeepEqual(want, have) {
+			t.Errorf("%s:\nhave: %+v\nwant: %+v", name, have, want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{JSON, YAML, TOML, RCJ} {
+		want := testJob()
+		var b bytes.Buffer
+		if err := Write(&b, want, format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		have, err := Parse(bytes.NewReader(b.Bytes()), format)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, b.String())
+		}
+		if !reflect.DeepEqual(want, have) {
+			t.Errorf("%s:\nhave: %+v\nwant: %+v\n%s", format, have, want, b.String())
+		}
+	}
+}
+
+func TestSaveJob(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.yml")
+	if err := SaveJob(path, testJob()); err != nil {
+		t.Fatal(err)
+	}
+	have, err := LoadJob(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testJob(); !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\nwant: %+v", have, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		format Format
+		text   string
+	}{
+		{JSON, `{"version": 1, "source": "a", "destination": "b", "copy": {"mirror": true}}`},
+		{YAML, "version: 1\nsource: a\ndestination: b\ncopy:\n  mirror: true\n"},
+		{TOML, "version = 1\nsource = 'a'\ndestination = 'b'\n[copy]\nmirror = true\n"},
+		{YAML, "version: 1\nsource: a\ndestination: b\nretry:\n  w: 30\n"},
+		{TOML, "version = 1\nsource = 'a'\ndestination = 'b'\n[throttling]\niorate = '10 apples'\n"},
+		{YAML, "version: 2\nsource: a\ndestination: b\n"},
+		{TOML, "source = 'a'\ndestination = 'b'\n"},
+	}
+	for _, test := range tests {
+		if _, err := Parse(strings.NewReader(test.text), test.format); err == nil {
+			t.Errorf("%s: %q: no error", test.format, test.text)
+		}
+	}
+	_, err := Parse(strings.NewReader("version: 2\nsource: a\ndestination: b\n"), YAML)
+	if !errors.Is(err, gorobocopy.ErrUnsupportedVersion) {
+		t.Errorf("have: %v, want: %v", err, gorobocopy.ErrUnsupportedVersion)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := map[string]Format{"a.json": JSON, "a.YAML": YAML, "a.yml": YAML, "dir/a.toml": TOML, "a.rcj": RCJ}
+	for path, want := range tests {
+		if have, err := FormatOf(path); have != want || err != nil {
+			t.Errorf("%s: have: %q, %v, want: %q", path, have, err, want)
+		}
+	}
+	if _, err := FormatOf("a.txt"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("a.txt: have: %v, want: %v", err, ErrUnknownFormat)
+	}
+}
@@ -0,0 +1,101 @@
+package gorobocopy
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(3, 4))
+	for i := 0; i < 2000; i++ {
+		want := NewRobocopy("src"+strconv.Itoa(i), "dst", randomName(rnd))
+		randomOptions(rnd, reflect.ValueOf(want).Elem())
+		data, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have := &Robocopy{}
+		if err := json.Unmarshal(data, have); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if !reflect.DeepEqual(want, have) {
+			t.Fatalf("%s:\nhave: %+v\nwant: %+v", data, have, want)
+		}
+	}
+}
+
+func TestConfigValues(t *testing.T) {
+	r := NewRobocopy("C:\\source", "D:\\dest", "")
+	r.SetCopyOptions(&CopyOptions{Mir: true, Copy: copyflags.D | copyflags.A | copyflags.T | copyflags.S})
+	r.SetThrottlingOptions(&CopyFileThrottlingOptions{Iorate: 10 * Megabyte})
+	r.SetRetryOptions(&RetryOptions{R: 3, W: 90 * time.Second})
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"version":1,"source":"C:\\source","destination":"D:\\dest","copy":{"copy":"DATS","mir":true},"throttling":{"iorate":"10MB"},"retry":{"r":3,"w":"1m30s"}}`
+	if string(data) != want {
+		t.Errorf("have: %s\nwant: %s", data, want)
+	}
+
+	have := &Robocopy{}
+	if err := json.Unmarshal([]byte(`{"version":1,"source":"a","destination":"b","throttling":{"iorate":"512k","threshold":"1 GB"},"retry":{"w":"2m","lfsmsize":"64mb"}}`), have); err != nil {
+		t.Fatal(err)
+	}
+	throttling := CopyFileThrottlingOptions{
+		Iorate:    512 * Kilobyte,
+		Threshold: Gigabyte,
+	}
+	if *have.GetThrottlingOptions() != throttling {
+		t.Errorf("throttling: have: %+v, want: %+v", *have.GetThrottlingOptions(), throttling)
+	}
+	retry := RetryOptions{W: 2 * time.Minute, LfsmSize: 64 * Megabyte}
+	if *have.GetRetryOptions() != retry {
+		t.Errorf("retry: have: %+v, want: %+v", *have.GetRetryOptions(), retry)
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	have := &Robocopy{}
+	if err := json.Unmarshal([]byte(`{"version":1,"source":"a","destination":"b","files":["*.docx","*.xlsx","report?.pdf"]}`), have); err != nil {
+		t.Fatal(err)
+	}
+	if files := have.GetFiles(); !slices.Equal(files, []string{"*.docx", "*.xlsx", "report?.pdf"}) {
+		t.Errorf("have: %q", files)
+	}
+	data, _ := json.Marshal(have)
+	if want := `{"version":1,"source":"a","destination":"b","files":["*.docx","*.xlsx","report?.pdf"]}`; string(data) != want {
+		t.Errorf("have: %s\nwant: %s", data, want)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []string{
+		`{"version":2,"source":"a","destination":"b"}`,
+		`{"source":"a","destination":"b"}`,
+		`{"version":1,"source":"a","destination":"b","copy":{"mirror":true}}`,
+		`{"version":1,"source":"a","destination":"b","copy":{"copy":"DAZ"}}`,
+		`{"version":1,"source":"a","destination":"b","throttling":{"iorate":"ten"}}`,
+		`{"version":1,"source":"a","destination":"b","throttling":{"iorate":"10TB"}}`,
+		`{"version":1,"source":"a","destination":"b","retry":{"w":"1.5s"}}`,
+		`{"version":1,"source":"a","destination":"b","retry":{"w":"soon"}}`,
+		`{"version":1,"source":"a","destination":"b","retry":{"wait":"5s"}}`,
+	}
+	for _, test := range tests {
+		if err := json.Unmarshal([]byte(test), &Robocopy{}); err == nil {
+			t.Errorf("%s: no error", test)
+		}
+	}
+	err := json.Unmarshal([]byte(tests[0]), &Robocopy{})
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("have: %v, want: %v", err, ErrUnsupportedVersion)
+	}
+}
@@ -0,0 +1,209 @@
+// Package engine copies files natively in Go, following the semantics of robocopy.
+//
+// It runs a *gorobocopy.Robocopy on any operating system and reports what it does with
+// the same events, summary and exit code bits the robocopy wrapper gets from parsing the
+// robocopy output. Executor plugs the engine into Robocopy.SetExecutor, so Run and
+// RunContext work unchanged.
+//
+// Options that only tune performance or Windows path handling (/z, /j, /256, /ipg,
+// /nooffload, /compress, /sparse, /eta and the throttling options) don't change the
+// outcome of a copy and are ignored. Options that would change the outcome but have no
+// POSIX equivalent, such as EFS, ACLs and file attributes, make Run fail with an
+// *UnsupportedError instead.
+package engine
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+	"github.com/aggellos2001/go-robocopy/flags/dcopyflags"
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+// UnsupportedError is returned by Run for options the engine can't honor.
+type UnsupportedError struct {
+	Switches []string // The unsupported switches, e.g. /efsraw or /copy:S.
+}
+
+func (e *UnsupportedError) Error() string {
+	return "engine: unsupported options: " + strings.Join(e.Switches, " ")
+}
+
+// Run copies the files as configured by r and returns the result of the run. The handler,
+// which may be nil, receives the events in the order robocopy prints them and honors the
+// logging options. With /mt the events of different files may interleave. The /log and
+// /log+ files are written in the robocopy layout.
+//
+// Commands that delete or move files are refused with a *gorobocopy.SafetyError if
+// CheckSafety fails, as RunContext does. Problems with individual files are reported as
+// events and reflected in the exit code. The returned error is only set if the options
+// are invalid, unsafe or unsupported, or if ctx is done before the run completes, in
+// which case the partial result is returned as well. A context done after the files were
+// copied doesn't count.
+func Run(ctx context.Context, r *gorobocopy.Robocopy, handler func(output.Event)) (*gorobocopy.Result, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.CheckSafety(); err != nil {
+		return nil, err
+	}
+	return run(ctx, r, handler)
+}
+
+// RunUnsafe is Run without the safety checks, as RunContext with RunOptions.Unsafe set.
+func RunUnsafe(ctx context.Context, r *gorobocopy.Robocopy, handler func(output.Event)) (*gorobocopy.Result, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return run(ctx, r, handler)
+}
+
+// Runs the command once it is validated.
+func run(ctx context.Context, r *gorobocopy.Robocopy, handler func(output.Event)) (*gorobocopy.Result, error) {
+	if err := unsupported(r); err != nil {
+		return nil, err
+	}
+	var log *os.File
+	if lopt := r.GetLoggingOptions(); lopt != nil && (lopt.Log != "" || lopt.LogPlus != "") {
+		var err error
+		if lopt.Log != "" {
+			log, err = os.Create(lopt.Log)
+		} else {
+			log, err = os.OpenFile(lopt.LogPlus, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o666)
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer log.Close()
+		formatter := &output.Formatter{W: log, Bytes: lopt.Bytes}
+		next := handler
+		handler = func(event output.Event) {
+			formatter.Format(event)
+			if next != nil {
+				next(event)
+			}
+		}
+	}
+	j := newJob(ctx, r, handler)
+	j.run()
+	return &gorobocopy.Result{ExitCode: j.code, Duration: j.summary.Times.Total, Summary: &j.summary}, j.err
+}
+
+// Executor runs robocopy commands with the engine instead of robocopy. It writes the
+// events to the command's stdout in the robocopy layout, so the summary is parsed the
+// same way as with robocopy. Like robocopy, nothing is written to stdout when a log file
+// is used without /tee. The safety checks are left to RunContext.
+type Executor struct{}
+
+func (Executor) Execute(ctx context.Context, cmd gorobocopy.Command) (gorobocopy.ExitCode, error) {
+	r, err := gorobocopy.ParseCommandLine(cmd.Args)
+	if err != nil {
+		return -1, err
+	}
+	var handler func(output.Event)
+	lopt := r.GetLoggingOptions()
+	if lopt == nil {
+		lopt = &gorobocopy.LoggingOptions{}
+	}
+	if cmd.Stdout != nil && (lopt.Tee || lopt.Log == "" && lopt.LogPlus == "") {
+		formatter := &output.Formatter{W: cmd.Stdout, Bytes: lopt.Bytes}
+		handler = func(event output.Event) {
+			formatter.Format(event)
+		}
+	}
+	// RunContext checked the safety of the command, unless it was told not to.
+	result, err := RunUnsafe(ctx, r, handler)
+	if result == nil {
+		return -1, err
+	}
+	return result.ExitCode, err
+}
+
+var _ gorobocopy.Executor = Executor{}
+
+// Returns an *UnsupportedError listing the switches of r that the engine can't honor.
+func unsupported(r *gorobocopy.Robocopy) error {
+	var switches []string
+	add := func(set bool, sw string) {
+		if set {
+			switches = append(switches, sw)
+		}
+	}
+	if c := r.GetCopyOptions(); c != nil {
+		add(c.B, "/b")
+		add(c.Zb, "/zb")
+		add(c.EsfRaw, "/efsraw")
+		if acl := c.Copy & (copyflags.S | copyflags.O | copyflags.U); acl != 0 {
+			add(true, "/copy:"+acl.String())
+		}
+		add(c.Dcopy&dcopyflags.E != 0, "/dcopy:E")
+		add(c.Sec, "/sec")
+		add(c.CopyAll, "/copyall")
+		add(c.SecFix, "/secfix")
+		add(c.APlus != 0, "/a+:"+c.APlus.String())
+		add(c.AMinus != 0, "/a-:"+c.AMinus.String())
+		add(c.Fat, "/fat")
+		add(c.Mon != 0, "/mon")
+		add(c.Mot != 0, "/mot")
+		add(c.Sj, "/sj")
+	}
+	if fso := r.GetFileSelectionOptions(); fso != nil {
+		add(fso.A, "/a")
+		add(fso.M, "/m")
+		add(fso.Ia != 0, "/ia:"+fso.Ia.String())
+		add(fso.Xa != 0, "/xa:"+fso.Xa.String())
+		add(fso.Im, "/im")
+		add(!fso.Maxlad.IsZero(), "/maxlad")
+		add(!fso.Minlad.IsZero(), "/minlad")
+	}
+	if ropt := r.GetRetryOptions(); ropt != nil {
+		add(ropt.Reg, "/reg")
+		add(ropt.Tbd, "/tbd")
+		add(ropt.Lfsm || ropt.LfsmSize != 0, "/lfsm")
+	}
+	if lopt := r.GetLoggingOptions(); lopt != nil {
+		add(lopt.UniLog != "", "/unilog")
+		add(lopt.UniLogPlus != "", "/unilog+")
+		add(lopt.Unicode, "/unicode")
+	}
+	if jopt := r.GetJobOptions(); jopt != nil {
+		add(jopt.Job != "", "/job")
+		add(jopt.Save != "", "/save")
+		add(jopt.Nosd, "/nosd")
+		add(jopt.Nodd, "/nodd")
+		add(jopt.If, "/if")
+	}
+	if len(switches) != 0 {
+		return &UnsupportedError{Switches: switches}
+	}
+	return nil
+}
+
+// Wraps a reader to stop copying once the context is done and to report progress.
+type progressReader struct {
+	ctx     context.Context
+	r       io.Reader
+	read    int64
+	size    int64
+	percent int64
+	report  func(percent int64) // nil if progress isn't reported
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.report != nil && p.size > 0 {
+		if percent := p.read * 100 / p.size; percent > p.percent && percent < 100 {
+			p.percent = percent
+			p.report(percent)
+		}
+	}
+	return n, err
+}
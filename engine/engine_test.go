@@ -0,0 +1,554 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+var base = time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)
+
+// Creates the files under dir. Names ending with a slash are directories, the value is
+// the content of files and the age of the file in hours is the number of exclamation
+// marks at the start of it.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		age := time.Duration(len(content)-len(strings.TrimLeft(content, "!"))) * time.Hour
+		if err := os.Chtimes(path, base, base.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Returns the files and empty directories under dir in the form accepted by writeTree.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	tree := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		name, _ := filepath.Rel(dir, path)
+		name = filepath.ToSlash(name)
+		if d.IsDir() {
+			if entries, err := os.ReadDir(path); err == nil && len(entries) == 0 {
+				tree[name+"/"] = ""
+			}
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		tree[name] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+type options struct {
+	copy    *gorobocopy.CopyOptions
+	fso     *gorobocopy.FileSelectionOptions
+	logging *gorobocopy.LoggingOptions
+}
+
+func TestRun(t *testing.T) {
+	source := map[string]string{
+		"a.txt":          "a",
+		"b.log":          "bb",
+		"README":         "readme",
+		"sub/c.txt":      "c",
+		"sub/deep/d.txt": "d",
+		"empty/":         "",
+		"bin/e.txt":      "e",
+	}
+	tests := []struct {
+		name     string
+		files    []string
+		opts     options
+		dest     map[string]string
+		want     map[string]string
+		wantCode gorobocopy.ExitCode
+	}{
+		{
+			name:     "top level only",
+			want:     map[string]string{"a.txt": "a", "b.log": "bb", "README": "readme"},
+			wantCode: gorobocopy.FilesCopied,
+		},
+		{
+			name:     "subdirectories without empty ones",
+			files:    []string{"*.txt"},
+			opts:     options{copy: &gorobocopy.CopyOptions{S: true}},
+			want:     map[string]string{"a.txt": "a", "sub/c.txt": "c", "sub/deep/d.txt": "d", "bin/e.txt": "e"},
+			wantCode: gorobocopy.FilesCopied,
+		},
+		{
+			name: "levels and exclusions",
+			opts: options{
+				copy: &gorobocopy.CopyOptions{E: true, Lev: 2},
+				fso:  &gorobocopy.FileSelectionOptions{Xf: []string{"*.LOG", "read??"}, Xd: []string{"b?n"}},
+			},
+			want:     map[string]string{"a.txt": "a", "sub/c.txt": "c", "empty/": ""},
+			wantCode: gorobocopy.FilesCopied,
+		},
+		{
+			name:     "nothing to do",
+			opts:     options{copy: &gorobocopy.CopyOptions{E: true}},
+			dest:     source,
+			want:     source,
+			wantCode: gorobocopy.AlreadyExist,
+		},
+		{
+			name:     "mirror",
+			opts:     options{copy: &gorobocopy.CopyOptions{Mir: true}},
+			dest:     map[string]string{"a.txt": "a", "old.txt": "old", "gone/x.txt": "x"},
+			want:     source,
+			wantCode: gorobocopy.FilesCopied | gorobocopy.ExtrasDetected,
+		},
+		{
+			name:     "extras are kept without purge",
+			dest:     map[string]string{"old.txt": "old", "gone/x.txt": "x"},
+			want:     map[string]string{"a.txt": "a", "b.log": "bb", "README": "readme", "old.txt": "old", "gone/x.txt": "x"},
+			wantCode: gorobocopy.FilesCopied | gorobocopy.ExtrasDetected,
+		},
+		{
+			name: "excluded extras",
+			opts: options{
+				copy: &gorobocopy.CopyOptions{Purge: true},
+				fso:  &gorobocopy.FileSelectionOptions{Xx: true},
+			},
+			dest:     map[string]string{"old.txt": "old"},
+			want:     map[string]string{"a.txt": "a", "b.log": "bb", "README": "readme", "old.txt": "old"},
+			wantCode: gorobocopy.FilesCopied,
+		},
+		{
+			name:     "mismatch",
+			files:    []string{"a.txt"},
+			dest:     map[string]string{"a.txt/": ""},
+			want:     map[string]string{"a.txt/": ""},
+			wantCode: gorobocopy.MismatchesDetected,
+		},
+		{
+			name:     "several file specifications",
+			files:    []string{"*.LOG", "read?e"},
+			want:     map[string]string{"b.log": "bb", "README": "readme"},
+			wantCode: gorobocopy.FilesCopied,
+		},
+		{
+			name:     "sizes",
+			opts:     options{fso: &gorobocopy.FileSelectionOptions{Min: 2, Max: 5}},
+			want:     map[string]string{"b.log": "bb"},
+			wantCode: gorobocopy.FilesCopied,
+		},
+		{
+			name:     "create",
+			files:    []string{"*.txt"},
+			opts:     options{copy: &gorobocopy.CopyOptions{Create: true}},
+			want:     map[string]string{"a.txt": ""},
+			wantCode: gorobocopy.FilesCopied,
+		},
+		{
+			name: "list only",
+			opts: options{
+				copy:    &gorobocopy.CopyOptions{Mir: true},
+				logging: &gorobocopy.LoggingOptions{L: true},
+			},
+			dest:     map[string]string{"old.txt": "old"},
+			want:     map[string]string{"old.txt": "old"},
+			wantCode: gorobocopy.FilesCopied | gorobocopy.ExtrasDetected,
+		},
+		{
+			name:     "parallel",
+			opts:     options{copy: &gorobocopy.CopyOptions{E: true, Mt: 4}},
+			want:     source,
+			wantCode: gorobocopy.FilesCopied,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := filepath.Join(t.TempDir(), "src"), filepath.Join(t.TempDir(), "dst")
+			writeTree(t, src, source)
+			writeTree(t, dst, test.dest)
+			r := gorobocopy.NewRobocopy(src, dst, test.files...)
+			r.SetCopyOptions(test.opts.copy)
+			r.SetFileSelectionOptions(test.opts.fso)
+			r.SetLoggingOptions(test.opts.logging)
+			result, err := Run(context.Background(), r, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ExitCode != test.wantCode {
+				t.Errorf("exit code: have: %d, want: %d", result.ExitCode, test.wantCode)
+			}
+			if have := readTree(t, dst); !reflect.DeepEqual(have, test.want) {
+				t.Errorf("destination:\nhave: %v\nwant: %v", have, test.want)
+			}
+		})
+	}
+}
+
+func TestRunClasses(t *testing.T) {
+	source := map[string]string{"same": "x", "newer": "x", "older": "!!x", "changed": "xx", "lonely": "x"}
+	dest := map[string]string{"same": "x", "newer": "!x", "older": "x", "changed": "x", "extra": "x"}
+	// Copying the same file changes nothing, so it only shows in the number of copied files.
+	// Like with robocopy, the classes of skipped files are lowercase.
+	tests := []struct {
+		fso     gorobocopy.FileSelectionOptions
+		want    []string
+		copied  int64
+		classes map[string]string
+	}{
+		{
+			gorobocopy.FileSelectionOptions{}, []string{"changed", "lonely", "newer", "older"}, 4,
+			map[string]string{"same": "same", "newer": "Newer", "older": "Older", "changed": "Changed", "lonely": "New File", "extra": "*EXTRA File"},
+		},
+		{
+			gorobocopy.FileSelectionOptions{Xo: true, Xc: true}, []string{"lonely", "newer"}, 2,
+			map[string]string{"same": "same", "newer": "Newer", "older": "older", "changed": "changed", "lonely": "New File", "extra": "*EXTRA File"},
+		},
+		{
+			gorobocopy.FileSelectionOptions{Xn: true, Xl: true}, []string{"changed", "older"}, 2,
+			map[string]string{"same": "same", "newer": "newer", "older": "Older", "changed": "Changed", "lonely": "lonely", "extra": "*EXTRA File"},
+		},
+		{
+			gorobocopy.FileSelectionOptions{Is: true, Xx: true}, []string{"changed", "lonely", "newer", "older"}, 5,
+			map[string]string{"same": "Same", "newer": "Newer", "older": "Older", "changed": "Changed", "lonely": "New File"},
+		},
+	}
+	for _, test := range tests {
+		src, dst := t.TempDir(), t.TempDir()
+		writeTree(t, src, source)
+		writeTree(t, dst, dest)
+		r := gorobocopy.NewRobocopy(src, dst, "")
+		r.SetFileSelectionOptions(&test.fso)
+		r.SetLoggingOptions(&gorobocopy.LoggingOptions{V: true, Np: true, Njh: true, Njs: true})
+		var copied []string
+		classes := map[string]string{}
+		result, err := Run(context.Background(), r, func(event output.Event) {
+			if e, ok := event.(output.FileEvent); ok {
+				classes[filepath.Base(e.Path)] = e.Class
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range readTree(t, dst) {
+			if content != dest[name] {
+				copied = append(copied, name)
+			}
+		}
+		slices.Sort(copied)
+		if !slices.Equal(test.want, copied) {
+			t.Errorf("%+v: have: %v, want: %v", test.fso, copied, test.want)
+		}
+		if result.Summary.Files.Copied != test.copied || result.Summary.Files.Total != 5 {
+			t.Errorf("%+v: unexpected summary %+v", test.fso, result.Summary.Files)
+		}
+		if !reflect.DeepEqual(test.classes, classes) {
+			t.Errorf("%+v: classes: have: %v, want: %v", test.fso, classes, test.classes)
+		}
+	}
+}
+
+func TestRunMove(t *testing.T) {
+	for _, move := range []bool{false, true} {
+		src, dst := t.TempDir(), t.TempDir()
+		writeTree(t, src, map[string]string{"a.txt": "a", "sub/b.txt": "b", "sub/c.tmp": "c", "done/d.txt": "d"})
+		r := gorobocopy.NewRobocopy(src, dst, "")
+		r.SetCopyOptions(&gorobocopy.CopyOptions{S: true, Mov: !move, Move: move})
+		r.SetFileSelectionOptions(&gorobocopy.FileSelectionOptions{Xf: []string{"*.tmp"}})
+		if _, err := Run(context.Background(), r, nil); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"sub/c.tmp": "c", "done/": ""}
+		if move {
+			delete(want, "done/")
+		}
+		if have := readTree(t, src); !reflect.DeepEqual(want, have) {
+			t.Errorf("move %v: have: %v, want: %v", move, have, want)
+		}
+	}
+}
+
+func TestRunNewDestination(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "a", "sub/b.txt": "bb", "empty/": ""})
+	var summaries []*output.Summary
+	for _, list := range []bool{true, false} {
+		r := gorobocopy.NewRobocopy(src, filepath.Join(t.TempDir(), "dst"), "")
+		r.SetCopyOptions(&gorobocopy.CopyOptions{E: true})
+		r.SetLoggingOptions(&gorobocopy.LoggingOptions{L: list})
+		var newDirs int
+		result, err := Run(context.Background(), r, func(event output.Event) {
+			if e, ok := event.(output.DirEvent); ok && e.Class == "New Dir" {
+				newDirs++
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if newDirs != 3 || result.Summary.Dirs.Copied != 3 {
+			t.Errorf("list %v: %d new directories, summary %+v", list, newDirs, result.Summary.Dirs)
+		}
+		summaries = append(summaries, result.Summary)
+	}
+	listed, copied := summaries[0], summaries[1]
+	if listed.Dirs != copied.Dirs || listed.Files != copied.Files || listed.Bytes != copied.Bytes {
+		t.Errorf("list: %+v %+v %+v\nrun: %+v %+v %+v", listed.Dirs, listed.Files, listed.Bytes, copied.Dirs, copied.Files, copied.Bytes)
+	}
+}
+
+func TestRunAge(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"new": "x", "old": "x", "older": "x"})
+	now := time.Now()
+	os.Chtimes(filepath.Join(src, "new"), now, now)
+	os.Chtimes(filepath.Join(src, "old"), now, now.AddDate(0, 0, -5))
+	os.Chtimes(filepath.Join(src, "older"), now, now.AddDate(0, 0, -20))
+	r := gorobocopy.NewRobocopy(src, dst, "")
+	r.SetFileSelectionOptions(&gorobocopy.FileSelectionOptions{Maxage: gorobocopy.AgeOf(10 * 24 * time.Hour), Minage: gorobocopy.AgeOf(2 * 24 * time.Hour)})
+	if _, err := Run(context.Background(), r, nil); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := readTree(t, dst), map[string]string{"old": "x"}; !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %v, want: %v", have, want)
+	}
+}
+
+func TestRunRetries(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	if err := os.Symlink(filepath.Join(src, "missing"), filepath.Join(src, "broken")); err != nil {
+		t.Skip("symbolic links not available:", err)
+	}
+	r := gorobocopy.NewRobocopy(src, dst, "")
+	r.SetRetryOptions(&gorobocopy.RetryOptions{R: 1, W: time.Second})
+	var events []string
+	result, err := Run(context.Background(), r, func(event output.Event) {
+		switch e := event.(type) {
+		case output.ErrorEvent:
+			events = append(events, "error "+e.Action)
+		case output.RetryEvent:
+			events = append(events, "retry "+e.Wait.String())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"error Copying File", "retry 1s", "error Copying File", "error "}
+	if !slices.Equal(want, events) {
+		t.Errorf("have: %q, want: %q", events, want)
+	}
+	if result.ExitCode != gorobocopy.CopyFailures || result.Summary.Files.Failed != 1 {
+		t.Errorf("unexpected result %d, %+v", result.ExitCode, result.Summary.Files)
+	}
+}
+
+func TestRunMissingSource(t *testing.T) {
+	r := gorobocopy.NewRobocopy(filepath.Join(t.TempDir(), "missing"), t.TempDir(), "")
+	result, err := Run(context.Background(), r, nil)
+	if err != nil || result.ExitCode != gorobocopy.FatalError {
+		t.Errorf("have: %v, %v", result, err)
+	}
+}
+
+func TestRunUnsupported(t *testing.T) {
+	r := gorobocopy.NewRobocopy(t.TempDir(), t.TempDir(), "")
+	r.SetCopyOptions(&gorobocopy.CopyOptions{Copy: copyflags.D | copyflags.S | copyflags.O, EsfRaw: true})
+	r.SetFileSelectionOptions(&gorobocopy.FileSelectionOptions{M: true})
+	_, err := Run(context.Background(), r, nil)
+	var uerr *UnsupportedError
+	if !errors.As(err, &uerr) || !slices.Equal(uerr.Switches, []string{"/efsraw", "/copy:SO", "/m"}) {
+		t.Errorf("have: %v", err)
+	}
+}
+
+func TestRunUnsafe(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a": "a", "sub/b": "b"})
+	tests := []struct {
+		dst  string
+		want error
+	}{
+		{"/", gorobocopy.ErrRootDestination},
+		{filepath.Join(src, "sub"), gorobocopy.ErrDestinationInSource},
+	}
+	for _, test := range tests {
+		r := gorobocopy.NewRobocopy(src, test.dst, "")
+		r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+		result, err := Run(context.Background(), r, nil)
+		if !errors.Is(err, test.want) || result != nil {
+			t.Errorf("%s: have: %v, %v", test.dst, result, err)
+		}
+	}
+	if tree := readTree(t, src); !reflect.DeepEqual(tree, map[string]string{"a": "a", "sub/b": "b"}) {
+		t.Errorf("files were deleted: %v", tree)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a": "a"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := Run(ctx, gorobocopy.NewRobocopy(src, dst, ""), nil)
+	if !errors.Is(err, context.Canceled) || result == nil || result.ExitCode.Copied() {
+		t.Errorf("have: %v, %v", result, err)
+	}
+}
+
+func TestRunCanceledAfterCopy(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a": "a"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result, err := Run(ctx, gorobocopy.NewRobocopy(src, dst, ""), func(event output.Event) {
+		if _, ok := event.(output.SummaryEvent); ok {
+			cancel()
+		}
+	})
+	if err != nil || result == nil || !result.ExitCode.Copied() {
+		t.Errorf("have: %v, %v", result, err)
+	}
+}
+
+func TestRunHours(t *testing.T) {
+	defer func(now func() time.Time, after func(time.Duration) <-chan time.Time) {
+		clockNow, clockAfter = now, after
+	}(clockNow, clockAfter)
+	clockNow = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local) }
+	var waits []time.Duration
+	clockAfter = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- clockNow().Add(d)
+		return ch
+	}
+	window := gorobocopy.RunWindow{Start: 22 * time.Hour, End: 6 * time.Hour}
+	tests := []struct {
+		copyOpt gorobocopy.CopyOptions
+		waits   int
+	}{
+		{gorobocopy.CopyOptions{Rh: window}, 1},           // once per pass
+		{gorobocopy.CopyOptions{Rh: window, Pf: true}, 2}, // before every copy
+		{gorobocopy.CopyOptions{Rh: gorobocopy.RunWindow{Start: 11 * time.Hour, End: 13 * time.Hour}, Pf: true}, 0},
+	}
+	for _, test := range tests {
+		waits = nil
+		src, dst := t.TempDir(), t.TempDir()
+		writeTree(t, src, map[string]string{"a": "a", "b": "b"})
+		r := gorobocopy.NewRobocopy(src, dst, "")
+		r.SetCopyOptions(&test.copyOpt)
+		result, err := Run(context.Background(), r, nil)
+		if err != nil || result.Summary.Files.Copied != 2 {
+			t.Fatalf("%+v: have: %v, %v", test.copyOpt, result, err)
+		}
+		if len(waits) != test.waits || len(waits) > 0 && waits[0] != 10*time.Hour {
+			t.Errorf("%+v: waits: %v", test.copyOpt, waits)
+		}
+	}
+
+	clockAfter = func(time.Duration) <-chan time.Time { return nil }
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a": "a"})
+	r := gorobocopy.NewRobocopy(src, dst, "")
+	r.SetCopyOptions(&gorobocopy.CopyOptions{Rh: window, Pf: true})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := Run(ctx, r, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || result == nil || result.ExitCode.Copied() {
+		t.Errorf("canceled wait: have: %v, %v", result, err)
+	}
+}
+
+// Running through the executor must produce the same summary as running directly.
+func TestExecutor(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "a", "sub/b.txt": "bb", "sub/c.tmp": "c"})
+	log := filepath.Join(t.TempDir(), "run.log")
+	r := gorobocopy.NewRobocopy(src, t.TempDir(), "")
+	r.SetCopyOptions(&gorobocopy.CopyOptions{E: true})
+	r.SetFileSelectionOptions(&gorobocopy.FileSelectionOptions{Xf: []string{"*.tmp"}})
+	r.SetLoggingOptions(&gorobocopy.LoggingOptions{LogPlus: log, Tee: true})
+	r.SetExecutor(Executor{})
+	var stdout strings.Builder
+	result, err := r.RunContext(context.Background(), gorobocopy.RunOptions{Stdout: &stdout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := output.Summary{
+		Dirs:  output.Counts{Total: 2, Skipped: 1, Copied: 1},
+		Files: output.Counts{Total: 3, Copied: 2, Skipped: 1},
+		Bytes: output.Counts{Total: 4, Copied: 3, Skipped: 1},
+	}
+	have := *result.Summary
+	have.Times, have.BytesPerSecond, have.MegabytesPerMinute, have.Ended = output.Times{}, 0, 0, ""
+	if result.ExitCode != gorobocopy.FilesCopied || !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %d %+v\nwant: %+v", result.ExitCode, have, want)
+	}
+	content, err := os.ReadFile(log)
+	if err != nil || string(content) != stdout.String() || !strings.Contains(stdout.String(), "New File") {
+		t.Errorf("log: %v\n%s\nstdout:\n%s", err, content, stdout.String())
+	}
+}
+
+func TestPlan(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"new": "x", "newer": "x", "same": "x", "sub/new": "xx"})
+	writeTree(t, dst, map[string]string{"newer": "!x", "same": "x", "extra": "xyz"})
+	r := gorobocopy.NewRobocopy(src, dst, "")
+	r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+	r.SetExecutor(Executor{})
+	plan, err := r.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	have := map[string]gorobocopy.PlanAction{}
+	for _, entry := range plan.Entries {
+		rel, err := filepath.Rel(src, entry.Path)
+		if strings.HasPrefix(entry.Path, dst) {
+			rel, err = filepath.Rel(dst, entry.Path)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		have[string(entry.Category)+" "+filepath.ToSlash(rel)] = entry.Action
+	}
+	want := map[string]gorobocopy.PlanAction{
+		"new new":     gorobocopy.PlanCopy,
+		"newer newer": gorobocopy.PlanCopy,
+		"same same":   gorobocopy.PlanSkip,
+		"extra extra": gorobocopy.PlanDelete,
+		"new sub":     gorobocopy.PlanCopy,
+		"new sub/new": gorobocopy.PlanCopy,
+	}
+	if !reflect.DeepEqual(want, have) || plan.CopyBytes != 4 || plan.DeleteBytes != 3 {
+		t.Errorf("have: %v, %d, %d\n%s", have, plan.CopyBytes, plan.DeleteBytes, plan)
+	}
+	if tree := readTree(t, dst); len(tree) != 3 {
+		t.Errorf("the destination was changed: %v", tree)
+	}
+}
@@ -0,0 +1,724 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+	"github.com/aggellos2001/go-robocopy/flags/dcopyflags"
+	"github.com/aggellos2001/go-robocopy/match"
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+// The robocopy defaults for /r and /w.
+const (
+	defaultRetries = 1000000
+	defaultWait    = 30 * time.Second
+)
+
+// The clock the run hours are checked against, replaced in tests.
+var (
+	clockNow   = time.Now
+	clockAfter = time.After
+)
+
+// The layout robocopy uses for the start and end times in English locales.
+const stampLayout = "Monday, January 2, 2006 3:04:05 PM"
+
+// Matches the file specifications and the /xf and /xd patterns. The files the engine
+// copies have no 8.3 short names, so only the long names are matched.
+var matcher match.Matcher
+
+// A single run of the engine.
+type job struct {
+	ctx        context.Context
+	r          *gorobocopy.Robocopy
+	handler    func(output.Event)
+	copyOpt    gorobocopy.CopyOptions
+	fso        gorobocopy.FileSelectionOptions
+	logging    gorobocopy.LoggingOptions
+	specs      []string
+	copyFlags  copyflags.CopyFlags
+	dcopyFlags dcopyflags.DCopyFlags
+	retries    int
+	wait       time.Duration
+	maxAge     time.Time // files older than this are excluded, zero if not set
+	minAge     time.Time // files newer than this are excluded, zero if not set
+	progress   bool
+
+	copies  chan func()
+	workers sync.WaitGroup
+	err     error // the context error if the run was stopped by it
+	dirs    []dir // the processed directories, finished once all files are copied
+
+	mu       sync.Mutex // guards the fields below and the calls to handler
+	summary  output.Summary
+	code     gorobocopy.ExitCode
+	copyTime time.Duration
+}
+
+type dir struct {
+	src, dst string
+	info     os.FileInfo
+}
+
+// A directory entry with the link resolved, unless the link itself is copied.
+type entry struct {
+	name string
+	info os.FileInfo
+	link bool
+}
+
+func newJob(ctx context.Context, r *gorobocopy.Robocopy, handler func(output.Event)) *job {
+	j := &job{
+		ctx:        ctx,
+		r:          r,
+		handler:    handler,
+		specs:      r.GetFiles(),
+		copyFlags:  copyflags.Default,
+		dcopyFlags: dcopyflags.Default,
+		retries:    defaultRetries,
+		wait:       defaultWait,
+	}
+	if c := r.GetCopyOptions(); c != nil {
+		j.copyOpt = *c
+		if c.Copy != 0 {
+			j.copyFlags = c.Copy
+		}
+		if c.Dcopy != 0 {
+			j.dcopyFlags = c.Dcopy
+		}
+		if c.NoCopy {
+			j.copyFlags = 0
+		}
+		if c.Nodcopy {
+			j.dcopyFlags = 0
+		}
+		if c.Mir {
+			j.copyOpt.E = true
+			j.copyOpt.Purge = true
+		}
+	}
+	if fso := r.GetFileSelectionOptions(); fso != nil {
+		j.fso = *fso
+		now := time.Now()
+		j.maxAge = fso.Maxage.Cutoff(now)
+		j.minAge = fso.Minage.Cutoff(now)
+	}
+	if ropt := r.GetRetryOptions(); ropt != nil {
+		if ropt.R != 0 {
+			j.retries = ropt.R
+		}
+		if ropt.W != 0 {
+			j.wait = ropt.W
+		}
+	}
+	if lopt := r.GetLoggingOptions(); lopt != nil {
+		j.logging = *lopt
+	}
+	j.progress = !j.logging.Np && j.copyOpt.Mt == 0
+	return j
+}
+
+func (j *job) emit(event output.Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.emitLocked(event)
+}
+
+func (j *job) emitLocked(event output.Event) {
+	if j.handler != nil {
+		j.handler(event)
+	}
+}
+
+func (j *job) emitError(action, path string, err error) {
+	event := output.ErrorEvent{Time: time.Now(), Action: action, Path: path, Message: err.Error()}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		event.Code = int(errno)
+	}
+	j.emit(event)
+}
+
+func (j *job) run() {
+	start := time.Now()
+	src, dst := filepath.Clean(j.r.GetSource()), filepath.Clean(j.r.GetDestination())
+	if !j.logging.Njh {
+		j.emit(j.header(start, src, dst))
+	}
+	if jopt := j.r.GetJobOptions(); jopt != nil && jopt.Quit {
+		return
+	}
+	info, err := os.Stat(src)
+	if err == nil && !info.IsDir() {
+		err = &os.PathError{Op: "stat", Path: src, Err: syscall.ENOTDIR}
+	}
+	if err != nil {
+		j.emitError("Accessing Source Directory", withSeparator(src), err)
+		j.code = gorobocopy.FatalError
+		return
+	}
+	// The root is classified before it is created, so a real run reports it as a new
+	// directory like a listing does.
+	dstExists := isDir(dst)
+	if !dstExists && !j.logging.L {
+		if err := os.MkdirAll(dst, 0o777); err != nil {
+			j.emitError("Creating Destination Directory", withSeparator(dst), err)
+			j.code = gorobocopy.FatalError
+			return
+		}
+	}
+
+	if !j.copyOpt.Pf {
+		j.waitRunHours() // a canceled wait is caught by j.dir
+	}
+	j.copies = make(chan func())
+	for i := 0; i < j.copyOpt.Mt; i++ {
+		j.workers.Add(1)
+		go func() {
+			defer j.workers.Done()
+			for fn := range j.copies {
+				fn()
+			}
+		}()
+	}
+	j.dir(src, dst, info, dstExists, 1, nil)
+	close(j.copies)
+	j.workers.Wait()
+	// The files and directories are skipped once the context is done, so a context done
+	// after this point didn't stop the run.
+	j.err = j.ctx.Err()
+	j.finishDirs()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	end := time.Now()
+	j.summary.Times.Total = end.Sub(start)
+	j.summary.Times.Copied = min(j.copyTime, j.summary.Times.Total)
+	if j.copyTime > 0 {
+		j.summary.BytesPerSecond = float64(j.summary.Bytes.Copied) / j.copyTime.Seconds()
+		j.summary.MegabytesPerMinute = j.summary.BytesPerSecond * 60 / (1 << 20)
+	}
+	j.summary.Ended = end.Format(stampLayout)
+	if !j.logging.Njs {
+		summary := j.summary
+		j.emitLocked(output.SummaryEvent{Summary: &summary})
+	}
+}
+
+func (j *job) header(start time.Time, src, dst string) output.HeaderEvent {
+	specs := j.specs
+	if len(specs) == 0 {
+		specs = []string{"*.*"}
+	}
+	options := slices.Clone(specs)
+	var args []string
+	if c := j.r.GetCopyOptions(); c != nil {
+		args = append(args, c.GetCommandArgs()...)
+	}
+	if t := j.r.GetThrottlingOptions(); t != nil {
+		args = append(args, t.GetCommandArgs()...)
+	}
+	if fso := j.r.GetFileSelectionOptions(); fso != nil {
+		for _, arg := range fso.GetCommandArgs() {
+			if strings.HasPrefix(arg, "/") {
+				args = append(args, arg) // the /xf and /xd lists are printed on their own
+			}
+		}
+	}
+	if ropt := j.r.GetRetryOptions(); ropt != nil {
+		args = append(args, ropt.GetCommandArgs()...)
+	}
+	if lopt := j.r.GetLoggingOptions(); lopt != nil {
+		args = append(args, lopt.GetCommandArgs()...)
+	}
+	for _, arg := range args {
+		name, value, found := strings.Cut(arg, ":")
+		if found {
+			value = ":" + value
+		}
+		options = append(options, strings.ToUpper(name)+value)
+	}
+	return output.HeaderEvent{
+		Started:       start.Format(stampLayout),
+		Source:        withSeparator(src),
+		Destination:   withSeparator(dst),
+		Files:         specs,
+		ExcludedFiles: j.fso.Xf,
+		ExcludedDirs:  j.fso.Xd,
+		Options:       strings.Join(options, " "),
+	}
+}
+
+func withSeparator(path string) string {
+	if strings.HasSuffix(path, string(filepath.Separator)) {
+		return path
+	}
+	return path + string(filepath.Separator)
+}
+
+// Processes a source directory and, depending on the options, its subdirectories.
+// Exists reports whether the destination directory existed before the run. The
+// ancestors are used to detect cycles through symbolic links.
+func (j *job) dir(src, dst string, info os.FileInfo, exists bool, level int, ancestors []os.FileInfo) {
+	if j.ctx.Err() != nil {
+		return
+	}
+	entries, err := j.readDir(src, j.copyOpt.Sl)
+	if err != nil {
+		j.emitError("Scanning Source Directory", withSeparator(src), err)
+		j.mu.Lock()
+		j.summary.Dirs.Total++
+		j.summary.Dirs.Failed++
+		j.code |= gorobocopy.CopyFailures
+		j.mu.Unlock()
+		return
+	}
+	existing := map[string]os.FileInfo{}
+	if exists {
+		dstEntries, _ := j.readDir(dst, true)
+		for _, e := range dstEntries {
+			existing[e.name] = e.info
+		}
+	}
+
+	var files, subdirs []entry
+	for _, e := range entries {
+		switch {
+		case e.info.IsDir():
+			if !(e.link && (j.fso.Xj || j.fso.Xjd)) {
+				subdirs = append(subdirs, e)
+			}
+		case e.link && (j.fso.Xj || j.fso.Xjf):
+		case e.info.Mode().IsRegular() || e.info.Mode()&os.ModeSymlink != 0:
+			if matcher.Spec(j.specs, e.name) {
+				files = append(files, e)
+			}
+		}
+	}
+
+	event := output.DirEvent{Files: int64(len(files)), Path: withSeparator(src)}
+	j.mu.Lock()
+	j.summary.Dirs.Total++
+	if exists {
+		j.summary.Dirs.Skipped++
+	} else {
+		event.Class = "New Dir"
+		j.summary.Dirs.Copied++
+	}
+	if !j.logging.Ndl {
+		j.emitLocked(event)
+	}
+	j.mu.Unlock()
+	if !exists && !j.logging.L && (level == 1 || j.copyOpt.E) {
+		// With /s the directory is created along with its first file.
+		if err := os.MkdirAll(dst, 0o777); err != nil {
+			j.emitError("Creating Destination Directory", withSeparator(dst), err)
+		}
+	}
+	j.dirs = append(j.dirs, dir{src: src, dst: dst, info: info})
+
+	for _, f := range files {
+		j.file(f, src, dst, existing[f.name])
+	}
+
+	recurse := (j.copyOpt.S || j.copyOpt.E) && (j.copyOpt.Lev == 0 || level < j.copyOpt.Lev)
+	if !j.fso.Xx {
+		j.extras(entries, src, dst, existing, recurse)
+	}
+	if !recurse {
+		return
+	}
+	ancestors = append(ancestors, info)
+	for _, d := range subdirs {
+		srcPath, dstPath := filepath.Join(src, d.name), filepath.Join(dst, d.name)
+		if matcher.Excluded(j.fso.Xd, d.name, srcPath, dstPath) || slices.ContainsFunc(ancestors, func(a os.FileInfo) bool { return os.SameFile(a, d.info) }) {
+			j.mu.Lock()
+			j.summary.Dirs.Total++
+			j.summary.Dirs.Skipped++
+			j.mu.Unlock()
+			continue
+		}
+		if info, ok := existing[d.name]; ok && !info.IsDir() {
+			j.mu.Lock()
+			j.summary.Dirs.Total++
+			j.summary.Dirs.Mismatch++
+			j.code |= gorobocopy.MismatchesDetected
+			if !j.logging.Ndl {
+				j.emitLocked(output.DirEvent{Class: "*MISMATCH", Files: -1, Path: withSeparator(srcPath)})
+			}
+			j.mu.Unlock()
+			continue
+		}
+		j.dir(srcPath, dstPath, d.info, isDir(dstPath), level+1, ancestors)
+	}
+}
+
+// Reports whether path is a directory, following symbolic links.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Reads a directory sorted by name. Symbolic links are resolved unless keepLinks is set,
+// links that can't be resolved are returned as they are and fail to copy later.
+func (j *job) readDir(path string, keepLinks bool) ([]entry, error) {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if err != nil {
+			continue // removed in the meantime
+		}
+		e := entry{name: de.Name(), info: info, link: info.Mode()&os.ModeSymlink != 0}
+		if e.link && !keepLinks {
+			if target, err := os.Stat(filepath.Join(path, e.name)); err == nil {
+				e.info = target
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Classifies a source file and copies it if it is selected.
+func (j *job) file(f entry, src, dst string, existing os.FileInfo) {
+	srcPath, dstPath := filepath.Join(src, f.name), filepath.Join(dst, f.name)
+	class, selected := j.classify(f, srcPath, dstPath, existing)
+	if !strings.HasPrefix(class, "*") {
+		// Robocopy capitalizes the classes of the files it copies.
+		if selected {
+			class = strings.ToUpper(class[:1]) + class[1:]
+		} else {
+			class = strings.ToLower(class)
+		}
+	}
+	size := f.info.Size()
+	j.mu.Lock()
+	j.summary.Files.Total++
+	j.summary.Bytes.Total += size
+	switch {
+	case class == "*Mismatch":
+		j.summary.Files.Mismatch++
+		j.summary.Bytes.Mismatch += size
+		j.code |= gorobocopy.MismatchesDetected
+		j.emitFileLocked(class, f.name, srcPath, f.info)
+	case !selected:
+		j.summary.Files.Skipped++
+		j.summary.Bytes.Skipped += size
+		if j.logging.V {
+			j.emitFileLocked(class, f.name, srcPath, f.info)
+		}
+	default:
+		j.emitFileLocked(class, f.name, srcPath, f.info)
+	}
+	j.mu.Unlock()
+
+	if !selected {
+		if j.copyOpt.TimFix && existing != nil && !existing.IsDir() && !j.logging.L {
+			os.Chtimes(dstPath, time.Time{}, f.info.ModTime())
+		}
+		return
+	}
+	if j.copyOpt.Pf && !j.waitRunHours() {
+		return
+	}
+	if j.copyOpt.Mt == 0 {
+		j.copyFile(f, srcPath, dstPath)
+	} else {
+		j.copies <- func() { j.copyFile(f, srcPath, dstPath) }
+	}
+}
+
+// Waits until the run hours allow new copies to start, like robocopy does once per pass,
+// or before every file with /pf. It returns false if ctx is done first. Nothing is copied
+// with /l, so there is nothing to wait for either.
+func (j *job) waitRunHours() bool {
+	if j.copyOpt.Rh.IsZero() || j.logging.L {
+		return true
+	}
+	now := clockNow()
+	open := j.copyOpt.Rh.NextOpen(now)
+	if !open.After(now) {
+		return true
+	}
+	select {
+	case <-j.ctx.Done():
+		return false
+	case <-clockAfter(open.Sub(now)):
+		return true
+	}
+}
+
+func (j *job) emitFileLocked(class, name, path string, info os.FileInfo) {
+	if j.logging.Nfl {
+		return
+	}
+	event := output.FileEvent{Class: class, Size: info.Size(), Name: name, Path: path}
+	if j.logging.Nc {
+		event.Class = ""
+	}
+	if j.logging.Ns {
+		event.Size = -1
+	}
+	if j.logging.Ts {
+		event.Time = info.ModTime().Truncate(time.Second)
+	}
+	if j.logging.Fp {
+		event.Name = path
+	}
+	j.emitLocked(event)
+}
+
+// Returns the robocopy file class of a source file and whether the file is selected for
+// copying. The existing destination file is nil if there is none.
+func (j *job) classify(f entry, srcPath, dstPath string, existing os.FileInfo) (string, bool) {
+	info := f.info
+	switch {
+	case matcher.Excluded(j.fso.Xf, f.name, srcPath, dstPath):
+		return "named", false
+	case j.fso.Max > 0 && info.Size() > int64(j.fso.Max):
+		return "too large", false
+	case j.fso.Min > 0 && info.Size() < int64(j.fso.Min):
+		return "too small", false
+	case !j.maxAge.IsZero() && info.ModTime().Before(j.maxAge):
+		return "too old", false
+	case !j.minAge.IsZero() && info.ModTime().After(j.minAge):
+		return "too new", false
+	}
+	if existing == nil {
+		if j.fso.Xl {
+			return "lonely", false
+		}
+		return "New File", true
+	}
+	if existing.IsDir() {
+		return "*Mismatch", false
+	}
+	diff := info.ModTime().Sub(existing.ModTime())
+	switch {
+	case j.sameTime(diff) && info.Size() == existing.Size():
+		if info.Mode() == existing.Mode() {
+			return "same", j.fso.Is
+		}
+		return "Tweaked", j.fso.It || j.fso.Is
+	case j.sameTime(diff):
+		return "Changed", !j.fso.Xc
+	case diff > 0:
+		return "Newer", !j.fso.Xn
+	default:
+		return "Older", !j.fso.Xo
+	}
+}
+
+// Reports whether two time stamps differing by d are considered the same, taking /fft
+// and /dst into account.
+func (j *job) sameTime(d time.Duration) bool {
+	var tolerance time.Duration
+	if j.fso.Fft {
+		tolerance = 2 * time.Second
+	}
+	d = d.Abs()
+	return d <= tolerance || j.fso.Dst && (d-time.Hour).Abs() <= tolerance
+}
+
+// Copies a file, retrying as configured by /r and /w.
+func (j *job) copyFile(f entry, srcPath, dstPath string) {
+	size := f.info.Size()
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		err := j.copyOnce(f, srcPath, dstPath)
+		if err == nil {
+			j.mu.Lock()
+			j.summary.Files.Copied++
+			j.summary.Bytes.Copied += size
+			j.code |= gorobocopy.FilesCopied
+			j.copyTime += time.Since(start)
+			j.mu.Unlock()
+			if (j.copyOpt.Mov || j.copyOpt.Move) && !j.logging.L {
+				if err := os.Remove(srcPath); err != nil {
+					j.emitError("Deleting Source File", srcPath, err)
+				}
+			}
+			return
+		}
+		if j.ctx.Err() != nil {
+			return
+		}
+		j.emitError("Copying File", srcPath, err)
+		if attempt >= j.retries {
+			j.mu.Lock()
+			j.summary.Files.Failed++
+			j.summary.Bytes.Failed += size
+			j.code |= gorobocopy.CopyFailures
+			j.emitLocked(output.ErrorEvent{Message: "RETRY LIMIT EXCEEDED."})
+			j.mu.Unlock()
+			return
+		}
+		j.emit(output.RetryEvent{Wait: j.wait})
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-time.After(j.wait):
+		}
+	}
+}
+
+func (j *job) copyOnce(f entry, srcPath, dstPath string) error {
+	if j.logging.L {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o777); err != nil {
+		return err
+	}
+	if f.link && j.copyOpt.Sl {
+		target, err := os.Readlink(srcPath)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(dstPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return os.Symlink(target, dstPath)
+	}
+	if j.copyFlags&copyflags.D != 0 {
+		if err := j.copyData(srcPath, dstPath, f.info.Size()); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(dstPath); err != nil {
+		return nil // no data to attach the other properties to
+	}
+	if j.copyFlags&copyflags.A != 0 {
+		if err := os.Chmod(dstPath, f.info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if j.copyFlags&copyflags.T != 0 {
+		return os.Chtimes(dstPath, time.Time{}, f.info.ModTime())
+	}
+	return nil
+}
+
+func (j *job) copyData(srcPath, dstPath string, size int64) error {
+	if j.copyOpt.Create {
+		out, err := os.Create(dstPath)
+		if err != nil {
+			return err
+		}
+		return out.Close()
+	}
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	reader := &progressReader{ctx: j.ctx, r: in, size: size}
+	if j.progress {
+		j.emit(output.ProgressEvent{Percent: 0, Path: srcPath})
+		reader.report = func(percent int64) {
+			j.emit(output.ProgressEvent{Percent: float64(percent), Path: srcPath})
+		}
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if j.progress {
+		j.emit(output.ProgressEvent{Percent: 100, Path: srcPath})
+	}
+	return nil
+}
+
+// Reports, and with /purge deletes, the destination entries that don't exist in the source.
+func (j *job) extras(entries []entry, src, dst string, existing map[string]os.FileInfo, recurse bool) {
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		names[e.name] = true
+	}
+	extras := make([]string, 0, len(existing))
+	for name := range existing {
+		if !names[name] {
+			extras = append(extras, name)
+		}
+	}
+	slices.Sort(extras)
+	purge := j.copyOpt.Purge && !j.logging.L
+	for _, name := range extras {
+		info, dstPath := existing[name], filepath.Join(dst, name)
+		if info.IsDir() {
+			if !recurse || matcher.Excluded(j.fso.Xd, name, filepath.Join(src, name), dstPath) {
+				continue
+			}
+			j.mu.Lock()
+			j.summary.Dirs.Extras++
+			j.code |= gorobocopy.ExtrasDetected
+			if !j.logging.Ndl {
+				j.emitLocked(output.DirEvent{Class: "*EXTRA Dir", Files: -1, Path: withSeparator(dstPath)})
+			}
+			j.mu.Unlock()
+			if purge {
+				if err := os.RemoveAll(dstPath); err != nil {
+					j.emitError("Deleting Extra Directory", withSeparator(dstPath), err)
+				}
+			}
+			continue
+		}
+		selected := matcher.Spec(j.specs, name) && !matcher.Excluded(j.fso.Xf, name, filepath.Join(src, name), dstPath)
+		if !selected && !j.logging.X {
+			continue
+		}
+		j.mu.Lock()
+		j.summary.Files.Extras++
+		j.summary.Bytes.Extras += info.Size()
+		j.code |= gorobocopy.ExtrasDetected
+		j.emitFileLocked("*EXTRA File", name, dstPath, info)
+		j.mu.Unlock()
+		if purge && selected {
+			if err := os.Remove(dstPath); err != nil {
+				j.emitError("Deleting Extra File", dstPath, err)
+			}
+		}
+	}
+}
+
+// Applies the directory properties selected with /dcopy and, with /move, removes the
+// source directories that are empty now. Subdirectories are handled before their parents.
+func (j *job) finishDirs() {
+	if j.logging.L || j.ctx.Err() != nil {
+		return
+	}
+	for i := len(j.dirs) - 1; i >= 0; i-- {
+		d := j.dirs[i]
+		if _, err := os.Stat(d.dst); err == nil {
+			if j.dcopyFlags&dcopyflags.A != 0 {
+				os.Chmod(d.dst, d.info.Mode().Perm())
+			}
+			if j.dcopyFlags&dcopyflags.T != 0 {
+				os.Chtimes(d.dst, time.Time{}, d.info.ModTime())
+			}
+		}
+		if j.copyOpt.Move {
+			os.Remove(d.src) // fails for directories with files that weren't moved
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package gorobocopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"time"
+)
+
+// Command is a single robocopy invocation handed to an Executor.
+type Command struct {
+	Args   []string  // Arguments as returned by GetCommandArgs.
+	Stdin  io.Reader // Nil means no input.
+	Stdout io.Writer // Never nil.
+	Stderr io.Writer // Never nil.
+	// How long to wait for robocopy to exit after the context is done before killing it.
+	GracePeriod time.Duration
+}
+
+// Executor runs robocopy commands. The default is ExecExecutor, which starts the real
+// robocopy executable. Other implementations can be set with SetExecutor, e.g. the fake
+// in the robocopytest package to test code that drives this library on any platform.
+type Executor interface {
+	// Execute runs the command and waits for it to finish. It returns the exit code of
+	// robocopy, which is not an error by itself. A non-nil error means robocopy could not
+	// be run or waited on, or that the context was done before it finished.
+	Execute(ctx context.Context, cmd Command) (ExitCode, error)
+}
+
+// ExecExecutor runs the robocopy executable using os/exec.
+type ExecExecutor struct {
+	// Path of the executable. If empty, robocopy is looked up in the PATH.
+	Path string
+}
+
+func (e ExecExecutor) Execute(ctx context.Context, c Command) (ExitCode, error) {
+	path := e.Path
+	if path == "" {
+		path = "robocopy"
+	}
+	cmd := exec.CommandContext(ctx, path, c.Args...)
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+	cmd.WaitDelay = c.GracePeriod
+	configureTermination(cmd)
+	// Exec only cancels robocopy if the context is done while it runs. A context done
+	// after robocopy exited doesn't make the run a stopped one.
+	var stopped atomic.Bool
+	terminate := cmd.Cancel
+	cmd.Cancel = func() error {
+		err := terminate()
+		if !errors.Is(err, os.ErrProcessDone) {
+			stopped.Store(true)
+		}
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return -1, fmt.Errorf("gorobocopy: starting robocopy: %w", err)
+	}
+	err := cmd.Wait()
+	code := ExitCode(cmd.ProcessState.ExitCode())
+	if stopped.Load() {
+		return code, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return code, err
+	}
+	return code, nil
+}
@@ -0,0 +1,86 @@
+package gorobocopy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Writes a shell script to stand in for robocopy.
+func fakeRobocopy(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of robocopy")
+	}
+	path := filepath.Join(t.TempDir(), "robocopy")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExecExecutor(t *testing.T) {
+	path := fakeRobocopy(t, "echo \"$@\"; echo oops >&2; exit 3\n")
+	var stdout, stderr strings.Builder
+	code, err := ExecExecutor{Path: path}.Execute(context.Background(), Command{
+		Args:   []string{"C:\\source", "D:\\destination", "/mir"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil || code != 3 || stdout.String() != "C:\\source D:\\destination /mir\n" || stderr.String() != "oops\n" {
+		t.Errorf("have: %d, %v, %q, %q", code, err, stdout.String(), stderr.String())
+	}
+}
+
+func TestExecExecutorGracefulCancellation(t *testing.T) {
+	path := fakeRobocopy(t, "trap 'kill $!; echo stopped; exit 1' INT\nsleep 10 &\nwait\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var stdout strings.Builder
+	code, err := ExecExecutor{Path: path}.Execute(ctx, Command{Stdout: &stdout, Stderr: &stdout, GracePeriod: 5 * time.Second})
+	if !errors.Is(err, context.DeadlineExceeded) || code != 1 || stdout.String() != "stopped\n" {
+		t.Errorf("have: %d, %v, %q", code, err, stdout.String())
+	}
+}
+
+func TestExecExecutorKillAfterGracePeriod(t *testing.T) {
+	path := fakeRobocopy(t, "trap '' INT\nexec sleep 10\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := ExecExecutor{Path: path}.Execute(ctx, Command{Stdout: os.Stdout, Stderr: os.Stderr, GracePeriod: 100 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 5*time.Second {
+		t.Errorf("have: %v after %v", err, time.Since(start))
+	}
+}
+
+// Cancels the context on the first write.
+type cancelingWriter struct {
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+func (w *cancelingWriter) Write(p []byte) (int, error) {
+	w.once.Do(w.cancel)
+	return len(p), nil
+}
+
+func TestExecExecutorCanceledAfterExit(t *testing.T) {
+	// Robocopy exits on its own with a success code, which Wait reports as an error, and
+	// the context is done while the output is still being read.
+	path := fakeRobocopy(t, "(sleep 0.1; echo late) &\nexit 1\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stdout := &cancelingWriter{cancel: cancel}
+	code, err := ExecExecutor{Path: path}.Execute(ctx, Command{Stdout: stdout, Stderr: io.Discard, GracePeriod: 5 * time.Second})
+	if err != nil || code != FilesCopied || ctx.Err() == nil {
+		t.Errorf("have: %d, %v", code, err)
+	}
+}
@@ -0,0 +1,111 @@
+package gorobocopy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExitCode is the exit code of robocopy. It is a bit field, each of the bit constants
+// below reports something that happened during the run and any combination of them
+// can be returned. Codes below 8 mean the run was successful.
+type ExitCode int
+
+// The individual bits of the exit code.
+const (
+	// One or more files were copied successfully.
+	FilesCopied ExitCode = 1 << iota
+	// Extra files or directories were detected in the destination. Examine the output log for details.
+	ExtrasDetected
+	// Some mismatched files or directories were detected. Examine the output log. Housekeeping might be required.
+	MismatchesDetected
+	// Some files or directories could not be copied and the retry limit was exceeded.
+	CopyFailures
+	// Serious error. Robocopy did not copy any files. Either a usage error or an error due to insufficient access privileges on the source or destination directories.
+	FatalError
+)
+
+// The combinations documented by Microsoft.
+const (
+	// No files were copied. No failure was encountered. No files were mismatched.
+	// The files already exist in the destination directory; therefore, the copy operation was skipped.
+	AlreadyExist ExitCode = 0
+	// All files were copied successfully.
+	AllFilesCopied = FilesCopied
+	// There are some additional files in the destination directory that aren't present in the source directory. No files were copied.
+	AdditionalFilesOnDest = ExtrasDetected
+	// Some files were copied. Additional files were present. No failure was encountered.
+	SomeFilesCopied = FilesCopied | ExtrasDetected
+	// Some files were copied. Some files were mismatched. No failure was encountered.
+	SomeFilesMismatched = FilesCopied | MismatchesDetected
+	// Additional files and mismatched files exist. No files were copied and no failures were encountered meaning that the files already exist in the destination directory.
+	AdditionalAndMismatchedFiles = ExtrasDetected | MismatchesDetected
+	// Files were copied, a file mismatch was present, and additional files were present.
+	FilesCopiedMismatchedAndAdditional = FilesCopied | ExtrasDetected | MismatchesDetected
+	// Several files didn't copy.
+	SeveralFilesDidntCopy = CopyFailures
+)
+
+// Reports whether files were copied.
+func (e ExitCode) Copied() bool {
+	return e >= 0 && e&FilesCopied != 0
+}
+
+// Reports whether extra files or directories were found in the destination.
+func (e ExitCode) HasExtras() bool {
+	return e >= 0 && e&ExtrasDetected != 0
+}
+
+// Reports whether mismatched files or directories were found.
+func (e ExitCode) HasMismatches() bool {
+	return e >= 0 && e&MismatchesDetected != 0
+}
+
+// Reports whether some files or directories could not be copied.
+func (e ExitCode) HasFailures() bool {
+	return e >= 0 && e&CopyFailures != 0
+}
+
+// Reports whether robocopy failed without copying anything.
+func (e ExitCode) Fatal() bool {
+	return e >= 0 && e&FatalError != 0
+}
+
+// Reports whether the run was successful, which is the case for all codes below 8.
+func (e ExitCode) IsSuccess() bool {
+	return e >= 0 && e < CopyFailures
+}
+
+func (e ExitCode) String() string {
+	if e == AlreadyExist {
+		return "No files were copied. No failure was encountered. No files were mismatched. The files already exist in the destination directory; therefore, the copy operation was skipped."
+	}
+	if e < 0 || e >= FatalError<<1 {
+		return "Unknown exit code"
+	}
+	var parts []string
+	if e.Copied() {
+		parts = append(parts, "One or more files were copied successfully.")
+	}
+	if e.HasExtras() {
+		parts = append(parts, "Extra files or directories were detected in the destination.")
+	}
+	if e.HasMismatches() {
+		parts = append(parts, "Some mismatched files or directories were detected.")
+	}
+	if e.HasFailures() {
+		parts = append(parts, "Some files or directories could not be copied.")
+	}
+	if e.Fatal() {
+		parts = append(parts, "A serious error occurred and robocopy did not copy any files.")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ExitError is returned by Run when robocopy exits with an exit code of 8 or higher.
+type ExitError struct {
+	Code ExitCode
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("robocopy: exit code %d: %s", int(e.Code), e.Code)
+}
@@ -0,0 +1,54 @@
+package gorobocopy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCodeBits(t *testing.T) {
+	tests := []struct {
+		code                                                   ExitCode
+		copied, extras, mismatches, failures, fatal, isSuccess bool
+	}{
+		{AlreadyExist, false, false, false, false, false, true},
+		{AllFilesCopied, true, false, false, false, false, true},
+		{FilesCopiedMismatchedAndAdditional, true, true, true, false, false, true},
+		{9, true, false, false, true, false, false},
+		{10, false, true, false, true, false, false},
+		{16, false, false, false, false, true, false},
+		{-1, false, false, false, false, false, false},
+	}
+	for _, test := range tests {
+		have := []bool{test.code.Copied(), test.code.HasExtras(), test.code.HasMismatches(), test.code.HasFailures(), test.code.Fatal(), test.code.IsSuccess()}
+		want := []bool{test.copied, test.extras, test.mismatches, test.failures, test.fatal, test.isSuccess}
+		for i := range have {
+			if have[i] != want[i] {
+				t.Errorf("%d: have: %v, want: %v", test.code, have, want)
+				break
+			}
+		}
+	}
+}
+
+func TestExitCodeString(t *testing.T) {
+	tests := map[ExitCode]string{
+		9:  "One or more files were copied successfully. Some files or directories could not be copied.",
+		16: "A serious error occurred and robocopy did not copy any files.",
+		32: "Unknown exit code",
+		-1: "Unknown exit code",
+	}
+	for code, want := range tests {
+		if have := code.String(); have != want {
+			t.Errorf("%d: have: %q, want: %q", code, have, want)
+		}
+	}
+}
+
+func TestExitError(t *testing.T) {
+	err := fmt.Errorf("nightly backup: %w", &ExitError{Code: 10})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || !exitErr.Code.HasFailures() || !exitErr.Code.HasExtras() {
+		t.Errorf("have: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package gorobocopy
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//go:generate go test -run TestFieldDocs -update
+
+// Explanation describes one argument of the robocopy command line.
+type Explanation struct {
+	Switch      string   `json:"switch"`            // The argument as emitted, e.g. /r:3 or /xf *.tmp *.bak.
+	Field       string   `json:"field"`             // The option field it comes from, e.g. RetryOptions.R.
+	Description string   `json:"description"`       // What it does, from the documentation of the field.
+	Dangerous   bool     `json:"dangerous"`         // It can delete data or bypass security.
+	Warning     string   `json:"warning,omitempty"` // Why it is dangerous.
+	Notes       []string `json:"notes,omitempty"`   // Implied behaviors and interactions with other switches.
+}
+
+// Returns the explanation as a line of text, followed by its warning and notes.
+func (e Explanation) String() string {
+	var b strings.Builder
+	b.WriteString(e.Switch + "\t" + e.Description)
+	if e.Dangerous {
+		b.WriteString("\n\tWARNING: " + e.Warning)
+	}
+	for _, note := range e.Notes {
+		b.WriteString("\n\tNote: " + note)
+	}
+	return b.String()
+}
+
+// The switches that can delete data or bypass security, by field.
+var dangerousFields = map[string]string{
+	"CopyOptions.Purge": "Deletes files and directories from the destination that don't exist in the source.",
+	"CopyOptions.Mir":   "Deletes files and directories from the destination that don't exist in the source. A wrong or empty source empties the destination.",
+	"CopyOptions.Mov":   "Deletes the copied files from the source.",
+	"CopyOptions.Move":  "Deletes the copied files and directories from the source.",
+	"CopyOptions.B":     "Bypasses the permissions (ACLs) of files and directories using the backup privilege.",
+	"CopyOptions.Zb":    "Falls back to backup mode, which bypasses the permissions (ACLs) of files and directories.",
+}
+
+// Explain returns an explanation for every argument of the command line, in the order
+// of GetCommandArgs. Each switch is mapped back to its option field and described with
+// the documentation of that field. Switches that can delete data or bypass security
+// are flagged as dangerous, and implied behaviors such as /mir implying /e are noted.
+func (r *Robocopy) Explain() []Explanation {
+	explanations := []Explanation{
+		{Switch: r.source, Field: "Source", Description: fieldDocs["Robocopy.source"]},
+		{Switch: r.destination, Field: "Destination", Description: fieldDocs["Robocopy.destination"]},
+	}
+	for _, file := range r.files {
+		explanations = append(explanations, Explanation{Switch: file, Field: "Files", Description: fieldDocs["Robocopy.files"]})
+	}
+	var switches []Explanation
+	for _, opts := range []any{r.copyOpt, r.throttlingOpt, r.fileslOpt, r.retryOpt, r.loggingOpt, r.jobOpt} {
+		switches = append(switches, explainOptions(opts)...)
+	}
+	// Keep the order of the command line, which doesn't always follow the fields.
+	args := r.GetCommandArgs()
+	position := func(e Explanation) int {
+		name, _, _ := strings.Cut(e.Switch, " ")
+		for i, arg := range args {
+			if arg == name {
+				return i
+			}
+		}
+		return len(args)
+	}
+	sort.SliceStable(switches, func(i, j int) bool { return position(switches[i]) < position(switches[j]) })
+	for i := range switches {
+		switches[i].Notes = r.notes(switches[i].Field)
+	}
+	return append(explanations, switches...)
+}
+
+// Returns the explanations of the fields that are set in an options struct pointer.
+func explainOptions(opts any) (explanations []Explanation) {
+	v := reflect.ValueOf(opts)
+	if v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			continue
+		}
+		// The arguments of the field alone.
+		single := reflect.New(v.Type())
+		single.Elem().Field(i).Set(v.Field(i))
+		args := single.Interface().(interface{ GetCommandArgs() []string }).GetCommandArgs()
+		if len(args) == 0 {
+			continue
+		}
+		field := v.Type().Name() + "." + v.Type().Field(i).Name
+		warning, dangerous := dangerousFields[field]
+		explanations = append(explanations, Explanation{
+			Switch:      strings.Join(args, " "),
+			Field:       field,
+			Description: fieldDocs[field],
+			Dangerous:   dangerous,
+			Warning:     warning,
+		})
+	}
+	return explanations
+}
+
+// Returns the notes about what the field implies, given the rest of the options.
+func (r *Robocopy) notes(field string) (notes []string) {
+	copt := CopyOptions{}
+	if r.copyOpt != nil {
+		copt = *r.copyOpt
+	}
+	fso := FileSelectionOptions{}
+	if r.fileslOpt != nil {
+		fso = *r.fileslOpt
+	}
+	excludes := len(fso.Xf) > 0 || len(fso.Xd) > 0
+	switch field {
+	case "CopyOptions.Mir":
+		notes = append(notes, "Implies /e and /purge.")
+		if excludes {
+			notes = append(notes, "Files and directories excluded with /xf and /xd are not deleted from the destination.")
+		}
+	case "CopyOptions.Purge":
+		if !copt.S && !copt.E && !copt.Mir {
+			notes = append(notes, "Only the top level of the destination is purged, as neither /s nor /e is set.")
+		}
+		if excludes {
+			notes = append(notes, "Files and directories excluded with /xf and /xd are not deleted from the destination.")
+		}
+	case "CopyOptions.E":
+		notes = append(notes, "Implies /s.")
+	case "CopyOptions.Move":
+		notes = append(notes, "Implies /mov. Source directories are removed once their files have been moved.")
+	case "CopyOptions.Sec":
+		notes = append(notes, "Implies /copy:DATS.")
+	case "CopyOptions.CopyAll":
+		notes = append(notes, "Implies /copy:DATSOU.")
+	case "CopyOptions.Zb":
+		notes = append(notes, "Implies /z, and /b for the files that can't be accessed otherwise.")
+	case "CopyOptions.Rh":
+		if !copt.Pf {
+			notes = append(notes, "The hours are only checked when a pass starts, use /pf to check them before every file.")
+		}
+	case "CopyOptions.Mon", "CopyOptions.Mot":
+		notes = append(notes, "Robocopy keeps running and monitoring the source until it is stopped.")
+	case "CopyOptions.Create":
+		notes = append(notes, "The files are created with a length of zero, their data is not copied.")
+	case "FileSelectionOptions.Xj":
+		notes = append(notes, "Implies /xjd and /xjf.")
+	case "FileSelectionOptions.M":
+		notes = append(notes, "Changes the source by resetting the Archive attribute of the copied files.")
+	case "LoggingOptions.L":
+		notes = append(notes, "Nothing is copied, deleted or time stamped, the other switches only affect the listing.")
+	case "RetryOptions.Reg":
+		notes = append(notes, "Changes the defaults of every later robocopy run on this computer.")
+	}
+	return notes
+}
@@ -0,0 +1,240 @@
+package gorobocopy
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+)
+
+var update = flag.Bool("update", false, "regenerate explain_docs.go from the field comments")
+
+// Reads the documentation of the Robocopy and option struct fields from gorobocopy.go,
+// keyed by Type.Field, without the switch syntax in brackets at the start.
+func readFieldDocs(t *testing.T) map[string]string {
+	file, err := parser.ParseFile(token.NewFileSet(), "gorobocopy.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok || (spec.Name.Name != "Robocopy" && !strings.HasSuffix(spec.Name.Name, "Options")) {
+			return false
+		}
+		for _, field := range st.Fields.List {
+			doc := field.Doc
+			if spec.Name.Name == "Robocopy" {
+				doc = field.Comment // only the positional arguments have one
+			}
+			if doc == nil {
+				continue
+			}
+			for _, name := range field.Names {
+				docs[spec.Name.Name+"."+name.Name] = strings.Join(strings.Fields(doc.Text()), " ")
+			}
+		}
+		return false
+	})
+	return docs
+}
+
+// Splits a field comment into the switch syntax in brackets and the description.
+func splitFieldDoc(doc string) (syntax, description string) {
+	if !strings.HasPrefix(doc, "[") {
+		return "", doc
+	}
+	depth := 0
+	for i, c := range doc {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			return doc[1:i], strings.TrimSpace(doc[i+1:])
+		}
+	}
+	return "", doc
+}
+
+// The generated field docs must match the field comments, and the switch named in each
+// comment must be the one the field emits.
+func TestFieldDocs(t *testing.T) {
+	docs := readFieldDocs(t)
+	descriptions := map[string]string{}
+	for field, doc := range docs {
+		syntax, description := splitFieldDoc(doc)
+		descriptions[field] = description
+		if strings.HasPrefix(field, "Robocopy.") {
+			continue
+		}
+		if syntax == "" {
+			t.Errorf("%s: no switch syntax in %q", field, doc)
+			continue
+		}
+		want := strings.FieldsFunc(syntax, func(c rune) bool { return strings.ContainsRune(" :<[", c) })[0]
+		if have := emittedSwitch(t, field); have != want {
+			t.Errorf("%s: emits %s, documented as %s", field, have, want)
+		}
+	}
+	for _, opts := range []any{CopyOptions{}, CopyFileThrottlingOptions{}, FileSelectionOptions{}, RetryOptions{}, LoggingOptions{}, JobOptions{}} {
+		typ := reflect.TypeOf(opts)
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Name() + "." + typ.Field(i).Name; descriptions[field] == "" {
+				t.Errorf("%s: no documentation", field)
+			}
+		}
+	}
+
+	if *update {
+		var b bytes.Buffer
+		b.WriteString("// Code generated by go test -run TestFieldDocs -update; DO NOT EDIT.\n\npackage gorobocopy\n\n")
+		b.WriteString("// The documentation of the option fields used by Explain, keyed by Type.Field.\n")
+		b.WriteString("var fieldDocs = map[string]string{\n")
+		fields := make([]string, 0, len(descriptions))
+		for field := range descriptions {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fmt.Fprintf(&b, "%q: %q,\n", field, descriptions[field])
+		}
+		b.WriteString("}\n")
+		src, err := format.Source(b.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile("explain_docs.go", src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if !reflect.DeepEqual(descriptions, fieldDocs) {
+		t.Error("explain_docs.go is out of date, run go generate")
+	}
+}
+
+// Returns the switch a field emits when set, without its value.
+func emittedSwitch(t *testing.T, field string) string {
+	typeName, fieldName, _ := strings.Cut(field, ".")
+	var opts reflect.Value
+	for _, v := range []any{&CopyOptions{}, &CopyFileThrottlingOptions{}, &FileSelectionOptions{}, &RetryOptions{}, &LoggingOptions{}, &JobOptions{}} {
+		if reflect.TypeOf(v).Elem().Name() == typeName {
+			opts = reflect.ValueOf(v)
+		}
+	}
+	switch v := opts.Elem().FieldByName(fieldName).Addr().Interface().(type) {
+	case *bool:
+		*v = true
+	case *int:
+		*v = 1
+	case *string:
+		*v = "x"
+	case *[]string:
+		*v = []string{"x"}
+	case *ByteSize:
+		*v = Kilobyte
+	case *AgeFilter:
+		*v = AgeOf(24 * time.Hour)
+	case *time.Duration:
+		*v = time.Second
+	case *RunWindow:
+		*v = RunWindow{Start: 22 * time.Hour, End: 6 * time.Hour}
+	default:
+		opts.Elem().FieldByName(fieldName).SetUint(1)
+	}
+	args := opts.Interface().(interface{ GetCommandArgs() []string }).GetCommandArgs()
+	if len(args) == 0 {
+		t.Fatalf("%s: no arguments", field)
+	}
+	name, _, _ := strings.Cut(args[0], ":")
+	return name
+}
+
+func TestExplain(t *testing.T) {
+	r := NewRobocopy("C:\\source", "D:\\dest", "*.*")
+	r.SetCopyOptions(&CopyOptions{Mir: true, CopyAll: true, Mt: 32, Copy: copyflags.D | copyflags.A})
+	r.SetFileSelectionOptions(&FileSelectionOptions{Xj: true, Xd: []string{"bin", "obj"}})
+	r.SetRetryOptions(&RetryOptions{R: 3, W: 5 * time.Second})
+
+	have := r.Explain()
+	var switches []string
+	for _, e := range have {
+		switches = append(switches, e.Switch)
+	}
+	want := []string{"C:\\source", "D:\\dest", "*.*", "/copy:DA", "/copyall", "/mir", "/mt:32", "/xd bin obj", "/xj", "/r:3", "/w:5"}
+	if !reflect.DeepEqual(switches, want) {
+		t.Fatalf("have: %q\nwant: %q", switches, want)
+	}
+	if args := strings.Join(r.GetCommandArgs(), " "); args != strings.Join(want, " ") {
+		t.Errorf("explanations out of order with %q", args)
+	}
+
+	mir := have[5]
+	if mir.Field != "CopyOptions.Mir" || !mir.Dangerous || mir.Warning == "" || !strings.HasPrefix(mir.Description, "Mirrors a directory tree") {
+		t.Errorf("mir: %+v", mir)
+	}
+	if len(mir.Notes) != 2 || mir.Notes[0] != "Implies /e and /purge." {
+		t.Errorf("mir notes: %q", mir.Notes)
+	}
+	if xj := have[8]; xj.Field != "FileSelectionOptions.Xj" || xj.Dangerous || len(xj.Notes) != 1 {
+		t.Errorf("xj: %+v", xj)
+	}
+	if w := have[10]; w.Field != "RetryOptions.W" || !strings.HasPrefix(w.Description, "Specifies the wait time between retries") {
+		t.Errorf("w: %+v", w)
+	}
+	if source := have[0]; source.Field != "Source" || source.Description != "Specifies the path to the source directory." {
+		t.Errorf("source: %+v", source)
+	}
+}
+
+func TestExplainFiles(t *testing.T) {
+	have := NewRobocopy("C:\\source", "D:\\dest", "*.docx", "*.xlsx").Explain()
+	if len(have) != 4 || have[2].Switch != "*.docx" || have[3].Switch != "*.xlsx" || have[3].Field != "Files" || have[3].Description == "" {
+		t.Errorf("have: %+v", have)
+	}
+	if have := NewRobocopy("C:\\source", "D:\\dest").Explain(); len(have) != 2 {
+		t.Errorf("no files: %+v", have)
+	}
+}
+
+func TestExplainDangerous(t *testing.T) {
+	tests := []struct {
+		opts      CopyOptions
+		dangerous bool
+	}{
+		{CopyOptions{Purge: true}, true},
+		{CopyOptions{Mir: true}, true},
+		{CopyOptions{Move: true}, true},
+		{CopyOptions{Mov: true}, true},
+		{CopyOptions{B: true}, true},
+		{CopyOptions{Zb: true}, true},
+		{CopyOptions{E: true}, false},
+		{CopyOptions{Z: true}, false},
+	}
+	for _, test := range tests {
+		r := NewRobocopy("a", "b", "")
+		r.SetCopyOptions(&test.opts)
+		e := r.Explain()
+		if last := e[len(e)-1]; last.Dangerous != test.dangerous || (last.Warning != "") != test.dangerous {
+			t.Errorf("%s: %+v", last.Switch, last)
+		}
+	}
+}
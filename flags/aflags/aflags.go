@@ -17,9 +17,21 @@ const (
 	N                    // Not content indexed
 	E                    // Encrypted
 	T                    // Temporary
-	O                    // Offline - only used in the [/a-]: flag
+	O                    // Offline - only valid in the [/a-:], [/ia:] and [/xa:] flags
 )
 
+// Settable are the attributes robocopy can set with [/a+:], which are all but O.
+const Settable = R | A | S | H | C | N | E | T
+
+// The letters of the flags, in the order of their bits.
+const letters = "RASHCNETO"
+
+// Parse returns the flags for a string of letters such as RASH, in any case and order. An
+// empty string gives no flags, and a letter that is not a flag gives flags.ErrInvalidFlag.
+func Parse(s string) (AFlags, error) {
+	return flags.FromLetters[AFlags](s, letters)
+}
+
 func (flag AFlags) String() string {
 	var r strings.Builder
 	if flags.Has(flag, R) {
@@ -51,3 +63,12 @@ func (flag AFlags) String() string {
 	}
 	return r.String()
 }
+
+func (flag AFlags) MarshalText() ([]byte, error) {
+	return []byte(flag.String()), nil
+}
+
+func (flag *AFlags) UnmarshalText(text []byte) (err error) {
+	*flag, err = Parse(string(text))
+	return err
+}
@@ -0,0 +1,45 @@
+package aflags
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aggellos2001/go-robocopy/flags"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		letters string
+		want    AFlags
+	}{
+		{"", 0},
+		{"RASH", R | A | S | H},
+		{"hsar", R | A | S | H},
+		{"RASHCNETO", Settable | O},
+		{"tt", T},
+	}
+	for _, test := range tests {
+		have, err := Parse(test.letters)
+		if err != nil || have != test.want {
+			t.Errorf("%q: have: %v %v, want: %v", test.letters, have, err, test.want)
+		}
+	}
+	for _, letters := range []string{"X", "RAZ", "R A"} {
+		if _, err := Parse(letters); !errors.Is(err, flags.ErrInvalidFlag) {
+			t.Errorf("%q: have: %v", letters, err)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	var flag AFlags
+	if err := flag.UnmarshalText([]byte("ohr")); err != nil || flag != R|H|O {
+		t.Errorf("have: %v, %v", flag, err)
+	}
+	if text, _ := flag.MarshalText(); string(text) != "RHO" {
+		t.Errorf("have: %s", text)
+	}
+	if err := flag.UnmarshalText([]byte("RQ")); err == nil {
+		t.Error("invalid flag accepted")
+	}
+}
@@ -19,6 +19,15 @@ const (
 	Default = D | A | T             // Data, Attributes, Time stamps
 )
 
+// The letters of the flags, in the order of their bits.
+const letters = "DATXSOU"
+
+// Parse returns the flags for a string of letters such as DATSOU, in any case and order. An
+// empty string gives no flags, and a letter that is not a flag gives flags.ErrInvalidFlag.
+func Parse(s string) (CopyFlags, error) {
+	return flags.FromLetters[CopyFlags](s, letters)
+}
+
 func (flag CopyFlags) String() string {
 	var r strings.Builder
 	if flags.Has(flag, D) {
@@ -44,3 +53,12 @@ func (flag CopyFlags) String() string {
 	}
 	return r.String()
 }
+
+func (flag CopyFlags) MarshalText() ([]byte, error) {
+	return []byte(flag.String()), nil
+}
+
+func (flag *CopyFlags) UnmarshalText(text []byte) (err error) {
+	*flag, err = Parse(string(text))
+	return err
+}
@@ -1,6 +1,11 @@
 package copyflags
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	"github.com/aggellos2001/go-robocopy/flags"
+)
 
 func TestString(t *testing.T) {
 	var have []CopyFlags = []CopyFlags{
@@ -17,3 +22,28 @@ func TestString(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	if have, err := Parse("datsou"); err != nil || have != Default|S|O|U {
+		t.Errorf("have: %v, %v", have, err)
+	}
+	if have, err := Parse(""); err != nil || have != 0 {
+		t.Errorf("empty: have: %v, %v", have, err)
+	}
+	if _, err := Parse("DAQ"); !errors.Is(err, flags.ErrInvalidFlag) {
+		t.Errorf("have: %v", err)
+	}
+}
+
+func TestText(t *testing.T) {
+	var flag CopyFlags
+	if err := flag.UnmarshalText([]byte("datsou")); err != nil || flag != Default|S|O|U {
+		t.Errorf("have: %v, %v", flag, err)
+	}
+	if text, _ := flag.MarshalText(); string(text) != "DATSOU" {
+		t.Errorf("have: %s", text)
+	}
+	if err := flag.UnmarshalText([]byte("DAQ")); err == nil {
+		t.Error("invalid flag accepted")
+	}
+}
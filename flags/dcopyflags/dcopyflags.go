@@ -17,6 +17,15 @@ const (
 	Default = D | A                // Data, Attributes
 )
 
+// The letters of the flags, in the order of their bits.
+const letters = "DATEX"
+
+// Parse returns the flags for a string of letters such as DAT, in any case and order. An
+// empty string gives no flags, and a letter that is not a flag gives flags.ErrInvalidFlag.
+func Parse(s string) (DCopyFlags, error) {
+	return flags.FromLetters[DCopyFlags](s, letters)
+}
+
 func (flag DCopyFlags) String() string {
 	var r strings.Builder
 	if flags.Has(flag, D) {
@@ -36,3 +45,12 @@ func (flag DCopyFlags) String() string {
 	}
 	return r.String()
 }
+
+func (flag DCopyFlags) MarshalText() ([]byte, error) {
+	return []byte(flag.String()), nil
+}
+
+func (flag *DCopyFlags) UnmarshalText(text []byte) (err error) {
+	*flag, err = Parse(string(text))
+	return err
+}
@@ -1,6 +1,11 @@
 package dcopyflags
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	"github.com/aggellos2001/go-robocopy/flags"
+)
 
 func TestString(t *testing.T) {
 	var have []DCopyFlags = []DCopyFlags{
@@ -17,3 +22,28 @@ func TestString(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	if have, err := Parse("dat"); err != nil || have != D|A|T {
+		t.Errorf("have: %v, %v", have, err)
+	}
+	if have, err := Parse(""); err != nil || have != 0 {
+		t.Errorf("empty: have: %v, %v", have, err)
+	}
+	if _, err := Parse("DO"); !errors.Is(err, flags.ErrInvalidFlag) {
+		t.Errorf("have: %v", err)
+	}
+}
+
+func TestText(t *testing.T) {
+	var flag DCopyFlags
+	if err := flag.UnmarshalText([]byte("DaT")); err != nil || flag != D|A|T {
+		t.Errorf("have: %v, %v", flag, err)
+	}
+	if text, _ := flag.MarshalText(); string(text) != "DAT" {
+		t.Errorf("have: %s", text)
+	}
+	if err := flag.UnmarshalText([]byte("DO")); err == nil {
+		t.Error("invalid flag accepted")
+	}
+}
@@ -1,5 +1,14 @@
 package flags
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A letter doesn't stand for any flag of the set.
+var ErrInvalidFlag = errors.New("invalid flag")
+
 type Flag uint16
 
 func Has[T ~uint16](flag, other T) bool {
@@ -17,3 +26,16 @@ func Clear[T ~uint16](flag *T, other T) {
 func Toggle[T ~uint16](flag *T, other T) {
 	*flag ^= other
 }
+
+// FromLetters parses a string of flag letters (case-insensitive), where letters[i] stands
+// for the flag 1<<i. An empty string gives no flags.
+func FromLetters[T ~uint16](value, letters string) (flag T, err error) {
+	for _, c := range strings.ToUpper(value) {
+		i := strings.IndexRune(letters, c)
+		if i < 0 {
+			return 0, fmt.Errorf("%w %q, valid flags are %s", ErrInvalidFlag, c, letters)
+		}
+		flag |= 1 << i
+	}
+	return flag, nil
+}
@@ -1,9 +1,12 @@
 package unitflags
 
 import (
+	"strconv"
+
 	"github.com/aggellos2001/go-robocopy/flags"
 )
 
+// UnitFlags is the unit suffix of a size switch. The zero value stands for plain bytes.
 type UnitFlags flags.Flag
 
 const (
@@ -12,12 +15,35 @@ const (
 	Gigabytes
 )
 
+// Returns the suffix robocopy takes for the unit, k, m or g, or an empty string for
+// plain bytes.
 func (c UnitFlags) String() string {
-	if c == Kilobytes {
+	switch c {
+	case 0:
+		return ""
+	case Kilobytes:
 		return "k"
-	} else if c == Megabytes {
+	case Megabytes:
 		return "m"
-	} else {
+	case Gigabytes:
 		return "g"
+	default:
+		return "UnitFlags(" + strconv.Itoa(int(c)) + ")"
+	}
+}
+
+// Returns the number of bytes in the unit, or zero for a value that isn't a unit.
+func (c UnitFlags) Bytes() int64 {
+	switch c {
+	case 0:
+		return 1
+	case Kilobytes:
+		return 1 << 10
+	case Megabytes:
+		return 1 << 20
+	case Gigabytes:
+		return 1 << 30
+	default:
+		return 0
 	}
 }
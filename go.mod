@@ -1,3 +1,8 @@
 module github.com/aggellos2001/go-robocopy
 
 go 1.22.0
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
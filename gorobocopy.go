@@ -1,24 +1,22 @@
-//go:build windows
-
 package gorobocopy
 
 import (
+	"context"
 	"io"
 	"os/exec"
-	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/aggellos2001/go-robocopy/flags/aflags"
 	"github.com/aggellos2001/go-robocopy/flags/copyflags"
 	"github.com/aggellos2001/go-robocopy/flags/dcopyflags"
-	"github.com/aggellos2001/go-robocopy/flags/unitflags"
-	"github.com/aggellos2001/go-robocopy/types"
+	"github.com/aggellos2001/go-robocopy/output"
 )
 
 type Robocopy struct {
-	source      string // Specifies the path to the source directory.
-	destination string // Specifies the path to the destination directory.
-	file        string // Specifies the file or files to be copied. Wildcard characters (* or ?) are supported. If you don't specify this parameter, *.* is used as the default value.
+	source      string   // Specifies the path to the source directory.
+	destination string   // Specifies the path to the destination directory.
+	files       []string // Specifies the file or files to be copied. Wildcard characters (* or ?) are supported. If you don't specify this parameter, *.* is used as the default value.
 
 	// Specifies the options to use with the robocopy command, including copy, file, retry, logging, and job options.
 	copyOpt       *CopyOptions
@@ -27,94 +25,97 @@ type Robocopy struct {
 	retryOpt      *RetryOptions
 	loggingOpt    *LoggingOptions
 	jobOpt        *JobOptions
+	executor      Executor
 	exitCode      ExitCode
+	summary       *output.Summary
 }
 
 type CopyOptions struct {
 	// [/s] Copies subdirectories. This option automatically excludes empty directories.
-	S bool
+	S bool `json:"s,omitempty" yaml:"s,omitempty" toml:"s,omitempty"`
 	// [/e] Copies subdirectories. This option automatically includes empty directories.
-	E bool
+	E bool `json:"e,omitempty" yaml:"e,omitempty" toml:"e,omitempty"`
 	// [/lev:n] Copies only the top n levels of the source directory tree.
-	Lev int
+	Lev int `json:"lev,omitempty" yaml:"lev,omitempty" toml:"lev,omitzero"`
 	// [/z] Copies files in restartable mode. In restartable mode, should a file copy be interrupted, robocopy can pick up where it left off rather than recopying the entire file.
-	Z bool
+	Z bool `json:"z,omitempty" yaml:"z,omitempty" toml:"z,omitempty"`
 	// [/b] Copies files in backup mode. In backup mode, robocopy overrides file and folder permission settings (ACLs), which might otherwise block access.
-	B bool
+	B bool `json:"b,omitempty" yaml:"b,omitempty" toml:"b,omitempty"`
 	// [/zb] Copies files in restartable mode. If file access is denied, switches to backup mode.
-	Zb bool
+	Zb bool `json:"zb,omitempty" yaml:"zb,omitempty" toml:"zb,omitempty"`
 	// [/j] Copies using unbuffered I/O (recommended for large files).
-	J bool
+	J bool `json:"j,omitempty" yaml:"j,omitempty" toml:"j,omitempty"`
 	// [/efsraw] Copies all encrypted files in EFS RAW mode.
-	EsfRaw bool
+	EsfRaw bool `json:"efsraw,omitempty" yaml:"efsraw,omitempty" toml:"efsraw,omitempty"`
 	// [/copy:<copyflags>] Specifies which file properties to copy. The valid values for this option are:
 	// D - Data, A - Attributes, T - Time stamps, X - Skip alt data streams, S - NTFS access control list (ACL), O - Owner information, U - Auditing information
 	// The default value for the /COPY option is DAT (data, attributes, and time stamps). The X flag is ignored if either /B or /ZB is used.
-	Copy copyflags.CopyFlags
+	Copy copyflags.CopyFlags `json:"copy,omitempty" yaml:"copy,omitempty" toml:"copy,omitzero"`
 	// [/dcopy:<copyflags>] Specifies what to copy in directories. The valid values for this option are:
 	// D - Data, A - Attributes, T - Time stamps, E - Extended attribute, X - Skip alt data streams
 	// The default value for this option is DA (data and attributes).
-	Dcopy dcopyflags.DCopyFlags
+	Dcopy dcopyflags.DCopyFlags `json:"dcopy,omitempty" yaml:"dcopy,omitempty" toml:"dcopy,omitzero"`
 	// [/sec] Copies files with security (equivalent to /copy:DATS).
-	Sec bool
+	Sec bool `json:"sec,omitempty" yaml:"sec,omitempty" toml:"sec,omitempty"`
 	// [/copyall] Copies all file information (equivalent to /copy:DATSOU).
-	CopyAll bool
+	CopyAll bool `json:"copyall,omitempty" yaml:"copyall,omitempty" toml:"copyall,omitempty"`
 	// [/nocopy] Copies no file information (useful with /purge).
-	NoCopy bool
+	NoCopy bool `json:"nocopy,omitempty" yaml:"nocopy,omitempty" toml:"nocopy,omitempty"`
 	// [/secfix] Fixes file security on all files, even skipped ones.
-	SecFix bool
+	SecFix bool `json:"secfix,omitempty" yaml:"secfix,omitempty" toml:"secfix,omitempty"`
 	// [/timfix] Fixes file times on all files, even skipped ones.
-	TimFix bool
+	TimFix bool `json:"timfix,omitempty" yaml:"timfix,omitempty" toml:"timfix,omitempty"`
 	// [/purge] Deletes destination files and directories that no longer exist in the source. Using this option with the /e option and a destination directory, allows the destination directory security settings to not be overwritten.
-	Purge bool
+	Purge bool `json:"purge,omitempty" yaml:"purge,omitempty" toml:"purge,omitempty"`
 	// [/mir]  	Mirrors a directory tree (equivalent to /e plus /purge). Using this option with the /e option and a destination directory, overwrites the destination directory security settings.
-	Mir bool
+	Mir bool `json:"mir,omitempty" yaml:"mir,omitempty" toml:"mir,omitempty"`
 	// [/mov] Moves files, and deletes them from the source after they're copied.
-	Mov bool
+	Mov bool `json:"mov,omitempty" yaml:"mov,omitempty" toml:"mov,omitempty"`
 	// [/move] Moves files and directories, and deletes them from the source after they're copied.
-	Move bool
+	Move bool `json:"move,omitempty" yaml:"move,omitempty" toml:"move,omitempty"`
 	// [/a+:[RASHCNET]]
 	// Adds the specified attributes to copied files. The valid values for this option are:
-	// R - Read only, A - Archive, S - System, H - Hidden, C - Compressed, N - Not content indexed, E - Encrypted, T - Temporary, O - Offline
-	APlus aflags.AFlags
+	// R - Read only, A - Archive, S - System, H - Hidden, C - Compressed, N - Not content indexed, E - Encrypted, T - Temporary
+	APlus aflags.AFlags `json:"a+,omitempty" yaml:"a+,omitempty" toml:"a+,omitzero"`
 	// [/a-:[RASHCNETO]]
 	// Removes the specified attributes from copied files. The valid values for this option are:
-	// Everything is the same as [/a+:] with the only addintional value O - Offline
-	AMinus aflags.AFlags
+	// Everything is the same as [/a+:] with the only additional value O - Offline
+	AMinus aflags.AFlags `json:"a-,omitempty" yaml:"a-,omitempty" toml:"a-,omitzero"`
 	// [/create] Creates a directory tree and zero-length files only.
-	Create bool
+	Create bool `json:"create,omitempty" yaml:"create,omitempty" toml:"create,omitempty"`
 	// [/fat] Creates destination files by using 8.3 character-length FAT file names only.
-	Fat bool
+	Fat bool `json:"fat,omitempty" yaml:"fat,omitempty" toml:"fat,omitempty"`
 	// [/256] Turns off support for paths longer than 256 characters.
-	NoMoreThan256 bool
+	NoMoreThan256 bool `json:"256,omitempty" yaml:"256,omitempty" toml:"256,omitempty"`
 	// [/mon:n] Monitors the source and runs again when more than n changes are detected.
-	Mon int
+	Mon int `json:"mon,omitempty" yaml:"mon,omitempty" toml:"mon,omitzero"`
 	// [/mot:m] Monitors the source and runs again in m minutes if changes are detected.
-	Mot int
+	Mot int `json:"mot,omitempty" yaml:"mot,omitempty" toml:"mot,omitzero"`
 	// [/rh:hhmm-hhmm] Specifies run times when new copies can be started.
-	Rh string
+	Rh RunWindow `json:"rh,omitempty" yaml:"rh,omitempty" toml:"rh,omitempty"`
 	// [/pf] Checks run times on a per file (not per-pass) basis.
-	Pf bool
+	Pf bool `json:"pf,omitempty" yaml:"pf,omitempty" toml:"pf,omitempty"`
 	// [/ipg:n] Specifies the inter-packet gap to free bandwidth on slow lines.
-	Ipg int
+	Ipg int `json:"ipg,omitempty" yaml:"ipg,omitempty" toml:"ipg,omitzero"`
 	// [/sj] Copies junctions (soft-links) to the destination path instead of link targets.
-	Sj bool
+	Sj bool `json:"sj,omitempty" yaml:"sj,omitempty" toml:"sj,omitempty"`
 	// [/sl] Don't follow symbolic links and instead create a copy of the link.
-	Sl bool
+	Sl bool `json:"sl,omitempty" yaml:"sl,omitempty" toml:"sl,omitempty"`
 	// [/mt:n] Creates multi-threaded copies with n threads. n must be an integer between 1 and 128. The default value for n is 8. For better performance, redirect your output using /log option.
 	//The /mt parameter can't be used with the /ipg and /efsraw parameters.
-	Mt int
+	Mt int `json:"mt,omitempty" yaml:"mt,omitempty" toml:"mt,omitzero"`
 	// [/nodcopy] Copies no directory info (the default /dcopy:DA is done).
-	Nodcopy bool
+	Nodcopy bool `json:"nodcopy,omitempty" yaml:"nodcopy,omitempty" toml:"nodcopy,omitempty"`
 	// [/nooffload] Copies files without using the Windows Copy Offload mechanism.
-	Nooffload bool
+	Nooffload bool `json:"nooffload,omitempty" yaml:"nooffload,omitempty" toml:"nooffload,omitempty"`
 	// [/compress] Requests network compression during file transfer, if applicable.
-	Compress bool
+	Compress bool `json:"compress,omitempty" yaml:"compress,omitempty" toml:"compress,omitempty"`
 	// [/sparse] Enables retaining the sparse state of files during copy.
-	Sparse bool
+	Sparse bool `json:"sparse,omitempty" yaml:"sparse,omitempty" toml:"sparse,omitempty"`
 }
 
-func (c *CopyOptions) getCommandArgs() (result []string) {
+// Returns the command arguments for these options only.
+func (c *CopyOptions) GetCommandArgs() (result []string) {
 	if c.S {
 		result = append(result, "/s")
 	}
@@ -193,8 +194,8 @@ func (c *CopyOptions) getCommandArgs() (result []string) {
 	if c.Mot != 0 {
 		result = append(result, "/mot:"+strconv.Itoa(c.Mot))
 	}
-	if c.Rh != "" {
-		result = append(result, "/rh:"+c.Rh)
+	if !c.Rh.IsZero() {
+		result = append(result, "/rh:"+c.Rh.String())
 	}
 	if c.Pf {
 		result = append(result, "/pf")
@@ -229,81 +230,83 @@ func (c *CopyOptions) getCommandArgs() (result []string) {
 // These throttling options are used to specify the maximum I/O bandwidth that Robocopy allows to be used in bytes per second. If not specifying in bytes per second, whole numbers can be used if k, m, or g are specified. The minimum I/O bandwidth that is throttled is 524288 bytes even if a lesser value is specified.
 type CopyFileThrottlingOptions struct {
 	// [/iomaxsize:n[kmg]] The requested max i/o size per read/write cycle in n kilobytes, megabytes, or gigabytes.
-	Iomaxsize types.Pair[int, unitflags.UnitFlags]
+	Iomaxsize ByteSize `json:"iomaxsize,omitempty" yaml:"iomaxsize,omitempty" toml:"iomaxsize,omitzero"`
 	// [/iorate:<n>[kmg]] The requested i/o rate in n kilobytes megabytes, or gigabytes per second.
-	Iorate types.Pair[int, unitflags.UnitFlags]
+	Iorate ByteSize `json:"iorate,omitempty" yaml:"iorate,omitempty" toml:"iorate,omitzero"`
 	// [/threshold:<n>[kmg]] The file size threshold for throttling in n kilobytes, megabytes, or gigabytes.
-	Threshold types.Pair[int, unitflags.UnitFlags]
+	Threshold ByteSize `json:"threshold,omitempty" yaml:"threshold,omitempty" toml:"threshold,omitzero"`
 }
 
-func (cfto *CopyFileThrottlingOptions) getCommandArgs() (result []string) {
-	if !reflect.ValueOf(cfto.Iomaxsize).IsZero() {
-		result = append(result, "/iomaxsize:"+strconv.Itoa(cfto.Iomaxsize.First)+cfto.Iomaxsize.Second.String())
+// Returns the command arguments for these options only.
+func (cfto *CopyFileThrottlingOptions) GetCommandArgs() (result []string) {
+	if cfto.Iomaxsize != 0 {
+		result = append(result, "/iomaxsize:"+cfto.Iomaxsize.String())
 	}
-	if !reflect.ValueOf(cfto.Iorate).IsZero() {
-		result = append(result, "/iorate:"+strconv.Itoa(cfto.Iorate.First)+cfto.Iorate.Second.String())
+	if cfto.Iorate != 0 {
+		result = append(result, "/iorate:"+cfto.Iorate.String())
 	}
-	if !reflect.ValueOf(cfto.Threshold).IsZero() {
-		result = append(result, "/threshold:"+strconv.Itoa(cfto.Threshold.First)+cfto.Threshold.Second.String())
+	if cfto.Threshold != 0 {
+		result = append(result, "/threshold:"+cfto.Threshold.String())
 	}
 	return result
 }
 
 type FileSelectionOptions struct {
 	// [/a] Copies only files for which the Archive attribute is set.
-	A bool
+	A bool `json:"a,omitempty" yaml:"a,omitempty" toml:"a,omitempty"`
 	// [/m] Copies only files for which the Archive attribute is set, and resets the Archive attribute.
-	M bool
+	M bool `json:"m,omitempty" yaml:"m,omitempty" toml:"m,omitempty"`
 	// [/ia:[RASHCNETO]] Includes only files for which any of the specified attributes are set. The valid values for this option are:
 	// R - Read only, A - Archive, S - System, H - Hidden, C - Compressed, N - Not content indexed, E - Encrypted, T - Temporary, O - Offline
-	Ia aflags.AFlags
-	// [//xa:[RASHCNETO]] Excludes files for which any of the specified attributes are set. The valid values for this option are the same as the [/ia] command.
-	Xa aflags.AFlags
+	Ia aflags.AFlags `json:"ia,omitempty" yaml:"ia,omitempty" toml:"ia,omitzero"`
+	// [/xa:[RASHCNETO]] Excludes files for which any of the specified attributes are set. The valid values for this option are the same as the [/ia] command.
+	Xa aflags.AFlags `json:"xa,omitempty" yaml:"xa,omitempty" toml:"xa,omitzero"`
 	// [/xf <filename>[...]] Excludes files that match the specified names or paths. Wildcard characters (* and ?) are supported.
-	Xf []string
+	Xf []string `json:"xf,omitempty" yaml:"xf,omitempty" toml:"xf,omitempty"`
 	// [/xd <directory>[...]] Excludes directories that match the specified names and paths.
-	Xd []string
+	Xd []string `json:"xd,omitempty" yaml:"xd,omitempty" toml:"xd,omitempty"`
 	// [/xc] Excludes existing files with the same timestamp, but different file sizes.
-	Xc bool
+	Xc bool `json:"xc,omitempty" yaml:"xc,omitempty" toml:"xc,omitempty"`
 	// [/xn] Source directory files newer than the destination are excluded from the copy.
-	Xn bool
+	Xn bool `json:"xn,omitempty" yaml:"xn,omitempty" toml:"xn,omitempty"`
 	// [/xo] Source directory files older than the destination are excluded from the copy.
-	Xo bool
+	Xo bool `json:"xo,omitempty" yaml:"xo,omitempty" toml:"xo,omitempty"`
 	// [/xx] Excludes extra files and directories present in the destination but not the source. Excluding extra files won't delete files from the destination.
-	Xx bool
+	Xx bool `json:"xx,omitempty" yaml:"xx,omitempty" toml:"xx,omitempty"`
 	// [/xl] Excludes "lonely" files and directories present in the source but not the destination. Excluding lonely files prevents any new files from being added to the destination.
-	Xl bool
+	Xl bool `json:"xl,omitempty" yaml:"xl,omitempty" toml:"xl,omitempty"`
 	// [/im] Include modified files (differing change times).
-	Im bool
+	Im bool `json:"im,omitempty" yaml:"im,omitempty" toml:"im,omitempty"`
 	// [/is] Includes the same files. Same files are identical in name, size, times, and all attributes.
-	Is bool
+	Is bool `json:"is,omitempty" yaml:"is,omitempty" toml:"is,omitempty"`
 	// [/it] Includes "tweaked" files. Tweaked files have the same name, size, and times, but different attributes.
-	It bool
+	It bool `json:"it,omitempty" yaml:"it,omitempty" toml:"it,omitempty"`
 	// [/max:n] Specifies the maximum file size (to exclude files bigger than n bytes).
-	Max int
+	Max ByteSize `json:"max,omitempty" yaml:"max,omitempty" toml:"max,omitzero"`
 	// [/min:n] Specifies the minimum file size (to exclude files smaller than n bytes).
-	Min int
+	Min ByteSize `json:"min,omitempty" yaml:"min,omitempty" toml:"min,omitzero"`
 	// [/maxage:n] Specifies the maximum file age (to exclude files older than n days or date).
-	Maxage int
+	Maxage AgeFilter `json:"maxage,omitempty" yaml:"maxage,omitempty" toml:"maxage,omitempty"`
 	// [/minage:n] Specifies the minimum file age (exclude files newer than n days or date).
-	Minage int
+	Minage AgeFilter `json:"minage,omitempty" yaml:"minage,omitempty" toml:"minage,omitempty"`
 	// [/maxlad:n] Specifies the maximum last access date (excludes files unused since n).
-	Maxlad int
+	Maxlad AgeFilter `json:"maxlad,omitempty" yaml:"maxlad,omitempty" toml:"maxlad,omitempty"`
 	// [/minlad:n] Specifies the minimum last access date (excludes files used since n) If n is less than 1900, n specifies the number of days. Otherwise, n specifies a date in the format YYYYMMDD.
-	Minlad int
+	Minlad AgeFilter `json:"minlad,omitempty" yaml:"minlad,omitempty" toml:"minlad,omitempty"`
 	// [/xj] Excludes junction points, which are normally included by default.
-	Xj bool
+	Xj bool `json:"xj,omitempty" yaml:"xj,omitempty" toml:"xj,omitempty"`
 	// [/fft] Assumes FAT file times (two-second precision).
-	Fft bool
+	Fft bool `json:"fft,omitempty" yaml:"fft,omitempty" toml:"fft,omitempty"`
 	// [/dst] Compensates for one-hour DST time differences.
-	Dst bool
+	Dst bool `json:"dst,omitempty" yaml:"dst,omitempty" toml:"dst,omitempty"`
 	// [/xjd] Excludes junction points for directories.
-	Xjd bool
+	Xjd bool `json:"xjd,omitempty" yaml:"xjd,omitempty" toml:"xjd,omitempty"`
 	// [/xjf] Excludes junction points for files.
-	Xjf bool
+	Xjf bool `json:"xjf,omitempty" yaml:"xjf,omitempty" toml:"xjf,omitempty"`
 }
 
-func (fso *FileSelectionOptions) getCommandArgs() (result []string) {
+// Returns the command arguments for these options only.
+func (fso *FileSelectionOptions) GetCommandArgs() (result []string) {
 	if fso.A {
 		result = append(result, "/a")
 	}
@@ -349,22 +352,22 @@ func (fso *FileSelectionOptions) getCommandArgs() (result []string) {
 		result = append(result, "/it")
 	}
 	if fso.Max != 0 {
-		result = append(result, "/max:"+strconv.Itoa(fso.Max))
+		result = append(result, "/max:"+strconv.FormatInt(int64(fso.Max), 10))
 	}
 	if fso.Min != 0 {
-		result = append(result, "/min:"+strconv.Itoa(fso.Min))
+		result = append(result, "/min:"+strconv.FormatInt(int64(fso.Min), 10))
 	}
-	if fso.Maxage != 0 {
-		result = append(result, "/maxage:"+strconv.Itoa(fso.Maxage))
+	if !fso.Maxage.IsZero() {
+		result = append(result, "/maxage:"+fso.Maxage.String())
 	}
-	if fso.Minage != 0 {
-		result = append(result, "/minage:"+strconv.Itoa(fso.Minage))
+	if !fso.Minage.IsZero() {
+		result = append(result, "/minage:"+fso.Minage.String())
 	}
-	if fso.Maxlad != 0 {
-		result = append(result, "/maxlad:"+strconv.Itoa(fso.Maxlad))
+	if !fso.Maxlad.IsZero() {
+		result = append(result, "/maxlad:"+fso.Maxlad.String())
 	}
-	if fso.Minlad != 0 {
-		result = append(result, "/minlad:"+strconv.Itoa(fso.Minlad))
+	if !fso.Minlad.IsZero() {
+		result = append(result, "/minlad:"+fso.Minlad.String())
 	}
 	if fso.Xj {
 		result = append(result, "/xj")
@@ -386,25 +389,26 @@ func (fso *FileSelectionOptions) getCommandArgs() (result []string) {
 
 type RetryOptions struct {
 	// [/r:<n>] Specifies the number of retries on failed copies. The default value of n is 1,000,000 (one million retries).
-	R int
-	// [/w:<n>] Specifies the wait time between retries, in seconds. The default value of n is 30 (wait time 30 seconds).
-	W int
+	R int `json:"r,omitempty" yaml:"r,omitempty" toml:"r,omitzero"`
+	// [/w:<n>] Specifies the wait time between retries, in whole seconds. The default value of n is 30 (wait time 30 seconds).
+	W time.Duration `json:"w,omitempty" yaml:"w,omitempty" toml:"w,omitzero"`
 	// [/reg] Saves the values specified in the /r and /w options as default settings in the registry.
-	Reg bool
+	Reg bool `json:"reg,omitempty" yaml:"reg,omitempty" toml:"reg,omitempty"`
 	// [/tbd] Specifies that the system waits for share names to be defined (retry error 67).
-	Tbd bool
+	Tbd bool `json:"tbd,omitempty" yaml:"tbd,omitempty" toml:"tbd,omitempty"`
 	// [/lfsm] Operate in low free space mode that enables copy, pause, and resume (see Remarks).
-	Lfsm bool
+	Lfsm bool `json:"lfsm,omitempty" yaml:"lfsm,omitempty" toml:"lfsm,omitempty"`
 	// [/lfsm:<n>[kmg]] Specifies the floor size in n kilobytes, megabytes, or gigabytes.
-	LfsmSize types.Pair[int, unitflags.UnitFlags]
+	LfsmSize ByteSize `json:"lfsmsize,omitempty" yaml:"lfsmsize,omitempty" toml:"lfsmsize,omitzero"`
 }
 
-func (ropt *RetryOptions) getCommandArgs() (result []string) {
+// Returns the command arguments for these options only.
+func (ropt *RetryOptions) GetCommandArgs() (result []string) {
 	if ropt.R != 0 {
 		result = append(result, "/r:"+strconv.Itoa(ropt.R))
 	}
 	if ropt.W != 0 {
-		result = append(result, "/w:"+strconv.Itoa(ropt.W))
+		result = append(result, "/w:"+strconv.FormatInt(int64(ropt.W/time.Second), 10))
 	}
 	if ropt.Reg {
 		result = append(result, "/reg")
@@ -415,56 +419,57 @@ func (ropt *RetryOptions) getCommandArgs() (result []string) {
 	if ropt.Lfsm {
 		result = append(result, "/lfsm")
 	}
-	if !reflect.ValueOf(ropt.LfsmSize).IsZero() {
-		result = append(result, "/lfsm:"+strconv.Itoa(ropt.LfsmSize.First)+ropt.LfsmSize.Second.String())
+	if ropt.LfsmSize != 0 {
+		result = append(result, "/lfsm:"+ropt.LfsmSize.String())
 	}
 	return result
 }
 
 type LoggingOptions struct {
 	// [/l] Specifies that files are to be listed only (and not copied, deleted, or time stamped).
-	L bool
+	L bool `json:"l,omitempty" yaml:"l,omitempty" toml:"l,omitempty"`
 	// [/x] Reports all extra files, not just the ones that are selected.
-	X bool
+	X bool `json:"x,omitempty" yaml:"x,omitempty" toml:"x,omitempty"`
 	// [/v] Produces verbose output, and shows all skipped files.
-	V bool
+	V bool `json:"v,omitempty" yaml:"v,omitempty" toml:"v,omitempty"`
 	// [/ts] Includes source file time stamps in the output.
-	Ts bool
+	Ts bool `json:"ts,omitempty" yaml:"ts,omitempty" toml:"ts,omitempty"`
 	// [/fp] Includes the full path names of the files in the output.
-	Fp bool
+	Fp bool `json:"fp,omitempty" yaml:"fp,omitempty" toml:"fp,omitempty"`
 	// [/bytes] Prints sizes, as bytes.
-	Bytes bool
+	Bytes bool `json:"bytes,omitempty" yaml:"bytes,omitempty" toml:"bytes,omitempty"`
 	// [/ns] Specifies that file sizes aren't to be logged.
-	Ns bool
+	Ns bool `json:"ns,omitempty" yaml:"ns,omitempty" toml:"ns,omitempty"`
 	// [/nc] Specifies that file classes aren't to be logged.
-	Nc bool
+	Nc bool `json:"nc,omitempty" yaml:"nc,omitempty" toml:"nc,omitempty"`
 	// [/nfl] Specifies that file names aren't to be logged.
-	Nfl bool
+	Nfl bool `json:"nfl,omitempty" yaml:"nfl,omitempty" toml:"nfl,omitempty"`
 	// [/ndl] Specifies that directory names aren't to be logged.
-	Ndl bool
+	Ndl bool `json:"ndl,omitempty" yaml:"ndl,omitempty" toml:"ndl,omitempty"`
 	// [/np] Specifies that the progress of the copying operation (the number of files or directories copied so far) won't be displayed.
-	Np bool
+	Np bool `json:"np,omitempty" yaml:"np,omitempty" toml:"np,omitempty"`
 	// [/eta] Shows the estimated time of arrival (ETA) of the copied files.
-	Eta bool
+	Eta bool `json:"eta,omitempty" yaml:"eta,omitempty" toml:"eta,omitempty"`
 	// [/log:logfile] Writes the status output to the log file (overwrites the existing log file).
-	Log string
+	Log string `json:"log,omitempty" yaml:"log,omitempty" toml:"log,omitempty"`
 	// [/log+:logfile] Writes the status output to the log file (appends the output to the existing log file).
-	LogPlus string
+	LogPlus string `json:"log+,omitempty" yaml:"log+,omitempty" toml:"log+,omitempty"`
 	// [/unilog:logfile] Writes the status output to the log file as unicode text (overwrites the existing log file).
-	UniLog string
+	UniLog string `json:"unilog,omitempty" yaml:"unilog,omitempty" toml:"unilog,omitempty"`
 	// [/unilog+:logfile] Writes the status output to the log file as Unicode text (appends the output to the existing log file).
-	UniLogPlus string
+	UniLogPlus string `json:"unilog+,omitempty" yaml:"unilog+,omitempty" toml:"unilog+,omitempty"`
 	// [/tee] Writes the status output to the console window, and to the log file.
-	Tee bool
+	Tee bool `json:"tee,omitempty" yaml:"tee,omitempty" toml:"tee,omitempty"`
 	// [/njh] Specifies that there's no job header.
-	Njh bool
+	Njh bool `json:"njh,omitempty" yaml:"njh,omitempty" toml:"njh,omitempty"`
 	// [/njs] Specifies that there's no job summary.
-	Njs bool
+	Njs bool `json:"njs,omitempty" yaml:"njs,omitempty" toml:"njs,omitempty"`
 	// [/unicode] Displays the status output as unicode text.
-	Unicode bool
+	Unicode bool `json:"unicode,omitempty" yaml:"unicode,omitempty" toml:"unicode,omitempty"`
 }
 
-func (lopt *LoggingOptions) getCommandArgs() (result []string) {
+// Returns the command arguments for these options only.
+func (lopt *LoggingOptions) GetCommandArgs() (result []string) {
 	if lopt.L {
 		result = append(result, "/l")
 	}
@@ -530,20 +535,21 @@ func (lopt *LoggingOptions) getCommandArgs() (result []string) {
 
 type JobOptions struct {
 	// [/job:jobname] Specifies that parameters are to be derived from the named job file. To run /job:jobname, you must first run the /save:jobname parameter to create the job file.
-	Job string
+	Job string `json:"job,omitempty" yaml:"job,omitempty" toml:"job,omitempty"`
 	// [/save:jobname] Specifies that parameters are to be saved to the named job file. This must be ran before running /job:jobname. All copy, retry, and logging options must be specified before this parameter.
-	Save string
+	Save string `json:"save,omitempty" yaml:"save,omitempty" toml:"save,omitempty"`
 	// [/quit] Quits after processing command line (to view parameters).
-	Quit bool
+	Quit bool `json:"quit,omitempty" yaml:"quit,omitempty" toml:"quit,omitempty"`
 	// [/nosd] Indicates that no source directory is specified.
-	Nosd bool
+	Nosd bool `json:"nosd,omitempty" yaml:"nosd,omitempty" toml:"nosd,omitempty"`
 	// [/nodd] Indicates that no destination directory is specified.
-	Nodd bool
+	Nodd bool `json:"nodd,omitempty" yaml:"nodd,omitempty" toml:"nodd,omitempty"`
 	// [/if] Includes the specified files.
-	If bool
+	If bool `json:"if,omitempty" yaml:"if,omitempty" toml:"if,omitempty"`
 }
 
-func (jopt *JobOptions) getCommandArgs() (result []string) {
+// Returns the command arguments for these options only.
+func (jopt *JobOptions) GetCommandArgs() (result []string) {
 	if jopt.Job != "" {
 		result = append(result, "/job:"+jopt.Job)
 	}
@@ -565,15 +571,66 @@ func (jopt *JobOptions) getCommandArgs() (result []string) {
 	return result
 }
 
-// NewRobocopy returns a new robocopy instance with the default options applied.
-func NewRobocopy(sourceDir, destinationDir, file string) *Robocopy {
-	return &Robocopy{
+// NewRobocopy returns a new robocopy instance with the default options applied. Without
+// any file specification robocopy copies *.*. Empty specifications are left out.
+func NewRobocopy(sourceDir, destinationDir string, files ...string) *Robocopy {
+	r := &Robocopy{
 		source:      sourceDir,
 		destination: destinationDir,
-		file:        file,
+	}
+	r.SetFiles(files...)
+	return r
+}
+
+// Returns the source directory.
+func (r *Robocopy) GetSource() string {
+	return r.source
+}
+
+// Returns the destination directory.
+func (r *Robocopy) GetDestination() string {
+	return r.destination
+}
+
+// Returns the file specifications, nil if there are none.
+func (r *Robocopy) GetFiles() []string {
+	return r.files
+}
+
+// Sets the file specifications. Empty specifications are left out.
+func (r *Robocopy) SetFiles(files ...string) {
+	r.files = nil
+	for _, file := range files {
+		if file != "" {
+			r.files = append(r.files, file)
+		}
 	}
 }
 
+func (r *Robocopy) GetCopyOptions() *CopyOptions {
+	return r.copyOpt
+}
+
+func (r *Robocopy) GetThrottlingOptions() *CopyFileThrottlingOptions {
+	return r.throttlingOpt
+}
+
+func (r *Robocopy) GetFileSelectionOptions() *FileSelectionOptions {
+	return r.fileslOpt
+}
+
+func (r *Robocopy) GetRetryOptions() *RetryOptions {
+	return r.retryOpt
+}
+
+func (r *Robocopy) GetLoggingOptions() *LoggingOptions {
+	return r.loggingOpt
+}
+
+func (r *Robocopy) GetJobOptions() *JobOptions {
+	return r.jobOpt
+}
+
 func (r *Robocopy) SetCopyOptions(opts *CopyOptions) {
 	r.copyOpt = opts
 }
@@ -598,29 +655,34 @@ func (r *Robocopy) SetJobOptions(opts *JobOptions) {
 	r.jobOpt = opts
 }
 
+// Sets the executor used to run the command. If nil, ExecExecutor is used.
+func (r *Robocopy) SetExecutor(executor Executor) {
+	r.executor = executor
+}
+
 // Returns the command arguments for the robocopy command in
 // the form of a string slice that can be used with exec.Command.
 func (r *Robocopy) GetCommandArgs() (command []string) {
 	command = append(command, r.source)
 	command = append(command, r.destination)
-	command = append(command, r.file)
+	command = append(command, r.files...)
 	if r.copyOpt != nil {
-		command = append(command, r.copyOpt.getCommandArgs()...)
+		command = append(command, r.copyOpt.GetCommandArgs()...)
 	}
 	if r.throttlingOpt != nil {
-		command = append(command, r.throttlingOpt.getCommandArgs()...)
+		command = append(command, r.throttlingOpt.GetCommandArgs()...)
 	}
 	if r.fileslOpt != nil {
-		command = append(command, r.fileslOpt.getCommandArgs()...)
+		command = append(command, r.fileslOpt.GetCommandArgs()...)
 	}
 	if r.retryOpt != nil {
-		command = append(command, r.retryOpt.getCommandArgs()...)
+		command = append(command, r.retryOpt.GetCommandArgs()...)
 	}
 	if r.loggingOpt != nil {
-		command = append(command, r.loggingOpt.getCommandArgs()...)
+		command = append(command, r.loggingOpt.GetCommandArgs()...)
 	}
 	if r.jobOpt != nil {
-		command = append(command, r.jobOpt.getCommandArgs()...)
+		command = append(command, r.jobOpt.GetCommandArgs()...)
 	}
 	return command
 }
@@ -634,62 +696,22 @@ func (r *Robocopy) GetCommand() *exec.Cmd {
 // Handles running the command and populating the exit code.
 // You can set the stdin, stdout, and stderr of the command.
 // If you set to nil, they will be set to the nul device (os.DevNull).
-// You can check the exit code using the GetExitCode() function.
-func (r *Robocopy) Run(stdin io.Reader, stdout, stderr io.Writer) {
-	cmd := r.GetCommand()
-	cmd.Stdin = stdin
-	cmd.Stdout = stdout
-	cmd.Stderr = stderr
-	cmd.Run()
-	r.exitCode = ExitCode(cmd.ProcessState.ExitCode())
-}
-
-type ExitCode int
-
-const (
-	// No files were copied. No failure was encountered. No files were mismatched.
-	// The files already exist in the destination directory; therefore, the copy operation was skipped.
-	AlreadyExist ExitCode = iota
-	// All files were copied successfully.
-	AllFilesCopied
-	// There are some additional files in the destination directory that aren't present in the source directory. No files were copied.
-	AdditionalFilesOnDest
-	// Some files were copied. Additional files were present. No failure was encountered.
-	SomeFilesCopied
-	// For some reason exit code 4 is skipped according to Microsoft's documentation.
-	_
-	// Some files were copied. Some files were mismatched. No failure was encountered.
-	SomeFilesMismatched
-	// Additional files and mismatched files exist. No files were copied and no failures were encountered meaning that the files already exist in the destination directory.
-	AdditionalAndMismatchedFiles
-	// Files were copied, a file mismatch was present, and additional files were present.
-	FilesCopiedMismatchedAndAdditional
-	// Several files didn't copy.
-	SeveralFilesDidntCopy
-)
+// The options are validated first and the command isn't started if Validate fails.
+// It returns an error if robocopy can't be started and an *ExitError if robocopy
+// reported failures (exit code 8 or higher).
+// You can check the exit code using the GetExitCode() function and the job summary
+// using the GetSummary() function. Use RunContext for cancellation and more details.
+func (r *Robocopy) Run(stdin io.Reader, stdout, stderr io.Writer) error {
+	_, err := r.RunContext(context.Background(), RunOptions{Stdin: stdin, Stdout: stdout, Stderr: stderr})
+	return err
+}
 
 func (r *Robocopy) GetExitCode() ExitCode {
 	return r.exitCode
 }
 
-func (e ExitCode) String() string {
-	switch e {
-	case AlreadyExist:
-		return "No files were copied. No failure was encountered. No files were mismatched. The files already exist in the destination directory; therefore, the copy operation was skipped."
-	case AllFilesCopied:
-		return "All files were copied successfully."
-	case AdditionalFilesOnDest:
-		return "There are some additional files in the destination directory that aren't present in the source directory. No files were copied."
-	case SomeFilesCopied:
-		return "Some files were copied. Additional files were present. No failure was encountered."
-	case SomeFilesMismatched:
-		return "Some files were copied. Some files were mismatched. No failure was encountered."
-	case AdditionalAndMismatchedFiles:
-		return "Additional files and mismatched files exist. No files were copied and no failures were encountered meaning that the files already exist in the destination directory."
-	case FilesCopiedMismatchedAndAdditional:
-		return "Files were copied, a file mismatch was present, and additional files were present."
-	case SeveralFilesDidntCopy:
-		return "Several files didn't copy."
-	}
-	return "Unknown exit code"
+// Returns the job summary robocopy printed during the last Run. It is nil if robocopy
+// didn't print one to stdout, e.g. because of /njs or /log without /tee.
+func (r *Robocopy) GetSummary() *output.Summary {
+	return r.summary
 }
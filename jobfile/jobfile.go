@@ -0,0 +1,236 @@
+// Package jobfile reads and writes robocopy job files (.RCJ), the files robocopy
+// creates with /save:jobname and reads back with /job:jobname.
+//
+// A job file holds one switch per line. Comments start with :: and run to the end of
+// the line. The source and destination directories are stored as /SD:path and /DD:path
+// and the included files, excluded directories and excluded files as /IF, /XD and /XF
+// followed by one name per line. A name such as /data is read as a POSIX path unless it
+// is the name of a switch.
+package jobfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+)
+
+// SyntaxError is returned by Parse when a line of the job file can't be interpreted.
+type SyntaxError struct {
+	Line int    // 1-based line number.
+	Text string // The line without its comment.
+	Err  error  // The reason the line was rejected.
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("jobfile: line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// A name line that doesn't follow /IF, /XD or /XF.
+var ErrNameOutsideList = errors.New("name outside of an /IF, /XD or /XF list")
+
+// Parse reads a job file and returns the Robocopy instance it describes. Both UTF-8
+// and UTF-16 (as written with /unicode) job files are accepted.
+func Parse(r io.Reader) (*gorobocopy.Robocopy, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var source, destination string
+	var files, xd, xf, switches []string
+	var list *[]string        // set while reading the names following /IF, /XD or /XF
+	lines := map[string]int{} // line of each switch for error reporting
+	scanner := bufio.NewScanner(bytes.NewReader(decode(data)))
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "::"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		// In a list, names unknown as switches are single-element POSIX paths such as /data.
+		if !strings.HasPrefix(text, "/") || list != nil && !knownSwitch(text) {
+			if list == nil {
+				return nil, &SyntaxError{Line: n, Text: text, Err: ErrNameOutsideList}
+			}
+			*list = append(*list, text)
+			continue
+		}
+		list = nil
+		name, value, _ := strings.Cut(text[1:], ":")
+		switch strings.ToLower(name) {
+		case "sd":
+			source = value
+		case "dd":
+			destination = value
+		case "if":
+			list = &files
+		case "xd":
+			list = &xd
+		case "xf":
+			list = &xf
+		default:
+			switches = append(switches, text)
+			if _, ok := lines[text]; !ok {
+				lines[text] = n
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{source, destination}, files...)
+	args = append(args, switches...)
+	if len(xf) != 0 {
+		args = append(append(args, "/xf"), xf...)
+	}
+	if len(xd) != 0 {
+		args = append(append(args, "/xd"), xd...)
+	}
+	cmd, err := gorobocopy.ParseCommandLine(args)
+	if err != nil {
+		var perr *gorobocopy.ParseError
+		if errors.As(err, &perr) {
+			return nil, &SyntaxError{Line: lines[perr.Token], Text: perr.Token, Err: perr.Err}
+		}
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// Reports whether the line is a switch of a job file or one robocopy knows, whatever
+// its value. Names holding a / after the first, such as /data/logs, are never switches.
+func knownSwitch(text string) bool {
+	name, _, _ := strings.Cut(text[1:], ":")
+	if strings.Contains(name, "/") {
+		return false
+	}
+	switch strings.ToLower(name) {
+	case "sd", "dd", "if":
+		return true
+	}
+	_, err := gorobocopy.ParseCommandLine([]string{"", "", text})
+	return !errors.Is(err, gorobocopy.ErrUnknownSwitch)
+}
+
+// Strips a byte order mark and converts UTF-16 content to UTF-8.
+func decode(data []byte) []byte {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	default:
+		return data
+	}
+	units := make([]uint16, (len(data)-2)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2+2*i:])
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// Write serializes the Robocopy instance as a job file. When JobOptions.Nosd or
+// JobOptions.Nodd is set the source or destination directory is left out of the file
+// and /NOSD or /NODD is written in its place. The file specifications are written as the
+// /IF list, which Parse reads back as file specifications. JobOptions.If is not written
+// on its own, as in a job file /IF only heads that list, so it doesn't survive a round
+// trip. The remaining job options (/job, /save and /quit) only make sense on the
+// command line and are not written.
+func Write(w io.Writer, r *gorobocopy.Robocopy) error {
+	jopt := r.GetJobOptions()
+	if jopt == nil {
+		jopt = &gorobocopy.JobOptions{}
+	}
+	var b strings.Builder
+	b.WriteString("::\r\n:: Robocopy Job\r\n::\r\n")
+	section := func(title string) {
+		fmt.Fprintf(&b, "\r\n::\r\n:: %s :\r\n::\r\n", title)
+	}
+	names := func(sw, comment string, names []string) {
+		fmt.Fprintf(&b, "\t%s\t\t:: %s\r\n", sw, comment)
+		for _, name := range names {
+			fmt.Fprintf(&b, "\t\t%s\r\n", name)
+		}
+	}
+	switches := func(title string, args []string) {
+		if len(args) == 0 {
+			return
+		}
+		section(title)
+		for _, arg := range args {
+			name, value, hasValue := strings.Cut(arg, ":")
+			if hasValue {
+				value = ":" + value
+			}
+			fmt.Fprintf(&b, "\t%s%s\r\n", strings.ToUpper(name), value)
+		}
+	}
+
+	if jopt.Nosd {
+		section("Source Directory")
+		b.WriteString("\t/NOSD\t\t:: NO Source Directory is specified.\r\n")
+	} else if source := r.GetSource(); source != "" {
+		section("Source Directory")
+		fmt.Fprintf(&b, "\t/SD:%s\t:: Source Directory.\r\n", source)
+	}
+	if jopt.Nodd {
+		section("Destination Directory")
+		b.WriteString("\t/NODD\t\t:: NO Destination Directory is specified.\r\n")
+	} else if destination := r.GetDestination(); destination != "" {
+		section("Destination Directory")
+		fmt.Fprintf(&b, "\t/DD:%s\t:: Destination Directory.\r\n", destination)
+	}
+	if files := r.GetFiles(); len(files) != 0 {
+		section("Include These Files")
+		names("/IF", "Include Files matching these names", files)
+	}
+
+	var fso gorobocopy.FileSelectionOptions
+	if opts := r.GetFileSelectionOptions(); opts != nil {
+		fso = *opts
+	}
+	if len(fso.Xd) != 0 {
+		section("Exclude These Directories")
+		names("/XD", "eXclude Directories matching these names", fso.Xd)
+	}
+	if len(fso.Xf) != 0 {
+		section("Exclude These Files")
+		names("/XF", "eXclude Files matching these names", fso.Xf)
+	}
+	fso.Xd, fso.Xf = nil, nil
+
+	if opts := r.GetCopyOptions(); opts != nil {
+		switches("Copy options", opts.GetCommandArgs())
+	}
+	if opts := r.GetThrottlingOptions(); opts != nil {
+		switches("Throttling Options", opts.GetCommandArgs())
+	}
+	switches("File Selection Options", fso.GetCommandArgs())
+	if opts := r.GetRetryOptions(); opts != nil {
+		switches("Retry Options", opts.GetCommandArgs())
+	}
+	if opts := r.GetLoggingOptions(); opts != nil {
+		switches("Logging Options", opts.GetCommandArgs())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,149 @@
+package jobfile
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+)
+
+func TestParse(t *testing.T) {
+	f, err := os.Open("testdata/nightly.rcj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cmd, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"C:\\Projects\\", "\\\\backup\\projects\\", "*.*",
+		"/s", "/e", "/copy:DAT", "/dcopy:DA", "/purge", "/mir", "/mt:16",
+		"/xf", "*.tmp", "~$*", "/xd", "node_modules", "C:\\Projects\\tmp",
+		"/r:3", "/w:5", "/np", "/log:C:\\Logs\\nightly.log",
+	}
+	if have := cmd.GetCommandArgs(); !slices.Equal(want, have) {
+		t.Errorf("have: %q\n,want: %q\n", have, want)
+	}
+}
+
+func TestParseUTF16(t *testing.T) {
+	text := "\t/SD:C:\\src\t:: Source Directory.\r\n\t/DD:D:\\dst\r\n\t/E\r\n"
+	var data bytes.Buffer
+	data.Write([]byte{0xFF, 0xFE})
+	for _, u := range utf16.Encode([]rune(text)) {
+		data.Write([]byte{byte(u), byte(u >> 8)})
+	}
+	cmd, err := Parse(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"C:\\src", "D:\\dst", "/e"}
+	if have := cmd.GetCommandArgs(); !slices.Equal(want, have) {
+		t.Errorf("have: %q\n,want: %q\n", have, want)
+	}
+}
+
+func TestParsePosixNames(t *testing.T) {
+	text := "/SD:/src\n/DD:/backup\n/XD\n\t/data\n\t/srv/cache\n\tnode_modules\n/XF\n\t/tmp\n/MIR\n/IF\n\t/e\n"
+	cmd, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A known switch ends the list, even if it was meant as a name.
+	want := []string{"/src", "/backup", "/e", "/mir", "/xf", "/tmp", "/xd", "/data", "/srv/cache", "node_modules"}
+	if have := cmd.GetCommandArgs(); !slices.Equal(want, have) {
+		t.Errorf("have: %q\n,want: %q\n", have, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		line int
+		err  error
+	}{
+		{"/SD:C:\\src\n\nstray\n", 3, ErrNameOutsideList},
+		{"/E\n/BOGUS :: not a switch\n", 2, gorobocopy.ErrUnknownSwitch},
+	}
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.text))
+		var serr *SyntaxError
+		if !errors.As(err, &serr) || serr.Line != test.line || !errors.Is(err, test.err) {
+			t.Errorf("%q: have: %v, want: line %d %v", test.text, err, test.line, test.err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	want := gorobocopy.NewRobocopy("C:\\source", "D:\\destination", "*.docx", "*.xlsx", "report?.pdf")
+	want.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true, Copy: copyflags.Default, Mt: 8})
+	want.SetFileSelectionOptions(&gorobocopy.FileSelectionOptions{Xd: []string{"bin", "obj"}, Xf: []string{"*.tmp"}, Xo: true})
+	want.SetRetryOptions(&gorobocopy.RetryOptions{R: 3, W: 5 * time.Second})
+	want.SetLoggingOptions(&gorobocopy.LoggingOptions{Log: "C:\\logs\\job.log", Np: true})
+	var buf bytes.Buffer
+	if err := Write(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	have, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %q\n,want: %q\n", have.GetCommandArgs(), want.GetCommandArgs())
+	}
+}
+
+func TestRoundTripIncludeFiles(t *testing.T) {
+	text := "/SD:C:\\src\r\n/DD:D:\\dst\r\n/IF\r\n\t*.docx\r\n\treport?.pdf\r\n/E\r\n"
+	cmd, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The /if switch only heads the /IF list and is not kept.
+	cmd.SetJobOptions(&gorobocopy.JobOptions{If: true})
+	var buf bytes.Buffer
+	if err := Write(&buf, cmd); err != nil {
+		t.Fatal(err)
+	}
+	have, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"C:\\src", "D:\\dst", "*.docx", "report?.pdf", "/e"}
+	if args := have.GetCommandArgs(); !slices.Equal(want, args) {
+		t.Errorf("have: %q\n,want: %q\n", args, want)
+	}
+	if have.GetJobOptions() != nil {
+		t.Errorf("job options: have: %+v", have.GetJobOptions())
+	}
+}
+
+func TestWriteNoSourceNoDestination(t *testing.T) {
+	cmd := gorobocopy.NewRobocopy("C:\\source", "D:\\destination", "")
+	cmd.SetJobOptions(&gorobocopy.JobOptions{Nosd: true, Nodd: true, Save: "template"})
+	var buf bytes.Buffer
+	if err := Write(&buf, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if text := buf.String(); strings.Contains(text, "/SD:") || strings.Contains(text, "/DD:") || strings.Contains(text, "/SAVE") {
+		t.Errorf("unexpected directories or command line options in:\n%s", text)
+	}
+	have, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", "", "/nosd", "/nodd"}
+	if args := have.GetCommandArgs(); !slices.Equal(want, args) {
+		t.Errorf("have: %q\n,want: %q\n", args, want)
+	}
+}
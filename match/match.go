@@ -0,0 +1,168 @@
+// Package match implements the wildcard matching robocopy applies to the file
+// specifications of a command and to the names and paths of /xf and /xd.
+//
+// It follows the rules Windows applies to file names rather than the ones of
+// path.Match, so it gives the same answers on any system:
+//
+//   - Case is ignored, * matches any run of characters and ? a single one.
+//   - A pattern ending in .* also matches the names without an extension, so *.* and
+//     readme.* match Makefile and readme.
+//   - A pattern ending in a dot only matches the names without an extension.
+//   - A ? at the end of the name or before a dot may match nothing, so file?.txt
+//     matches file.txt.
+//   - With short names enabled, a name also matches through its 8.3 short name, so
+//     *.htm matches index.html, whose short name is INDEX~1.HTM.
+//   - Patterns with a path separator are matched against full paths, the others
+//     against names.
+package match
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Matcher matches names the way robocopy does. The zero value matches the long names only.
+type Matcher struct {
+	// Also match the 8.3 short names Windows gives to long names, as robocopy does on the
+	// volumes that keep them. The short names are predicted by ShortName.
+	ShortNames bool
+}
+
+// Name reports whether the file or directory name matches the pattern.
+func (m Matcher) Name(pattern, name string) bool {
+	if matchName(pattern, name) {
+		return true
+	}
+	if m.ShortNames {
+		if alias, ok := ShortName(name); ok {
+			return matchName(pattern, alias)
+		}
+	}
+	return false
+}
+
+// Spec reports whether the file name is selected by the file specifications of a
+// command, that is when there are none or when it matches one of them.
+func (m Matcher) Spec(specs []string, name string) bool {
+	if len(specs) == 0 {
+		return true
+	}
+	for _, spec := range specs {
+		if m.Name(spec, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Excluded reports whether one of the /xf or /xd patterns excludes the file or directory
+// with the given name and full paths, usually its source and destination paths. Patterns
+// without a path separator are matched against the name and the others against the paths.
+func (m Matcher) Excluded(patterns []string, name string, paths ...string) bool {
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, `\/`) {
+			if m.Name(pattern, name) {
+				return true
+			}
+			continue
+		}
+		for _, path := range paths {
+			if Path(pattern, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Path reports whether the full path matches the pattern. Both kinds of separators are
+// the same and trailing ones are ignored. Short names play no part in paths.
+func Path(pattern, path string) bool {
+	clean := func(s string) []rune {
+		return []rune(strings.TrimRight(strings.ReplaceAll(s, `\`, "/"), "/"))
+	}
+	return match(clean(pattern), clean(path))
+}
+
+// Reports whether the name matches the pattern, with the rules for extensions.
+func matchName(pattern, name string) bool {
+	extension := strings.Contains(name, ".")
+	switch {
+	case strings.HasSuffix(pattern, ".*") && !extension:
+		return match([]rune(pattern[:len(pattern)-2]), []rune(name))
+	case strings.HasSuffix(pattern, ".") && !strings.HasSuffix(pattern, ".."):
+		return !extension && match([]rune(pattern[:len(pattern)-1]), []rune(name))
+	}
+	return match([]rune(pattern), []rune(name))
+}
+
+// Reports whether the name matches the pattern, where * matches any run of characters
+// and ? a single character, or none at the end of the name or before a dot.
+func match(pattern, name []rune) bool {
+	// Tracks for every prefix of the name whether the pattern so far matches it, which
+	// keeps patterns with many stars from backtracking for ever.
+	prefix := make([]bool, len(name)+1)
+	prefix[0] = true
+	for _, c := range pattern {
+		next := make([]bool, len(name)+1)
+		for i := range next {
+			switch c {
+			case '*':
+				next[i] = prefix[i] || i > 0 && next[i-1]
+			case '?':
+				next[i] = i > 0 && prefix[i-1] || prefix[i] && (i == len(name) || name[i] == '.')
+			default:
+				next[i] = i > 0 && prefix[i-1] && unicode.ToUpper(c) == unicode.ToUpper(name[i-1])
+			}
+		}
+		prefix = next
+	}
+	return prefix[len(name)]
+}
+
+// ShortName returns the 8.3 short name Windows gives to the name, and false if the name
+// is a valid short name already and gets none. Windows numbers the short names that
+// would collide, INDEX~1.HTM, INDEX~2.HTM and so on, but only the first one can be
+// predicted from the name alone.
+func ShortName(name string) (string, bool) {
+	base, ext := name, ""
+	if dot := strings.LastIndex(name, "."); dot > 0 {
+		base, ext = name[:dot], name[dot+1:]
+	}
+	if short(base, 8) && (ext == "" && base == name || short(ext, 3)) {
+		return "", false
+	}
+	base, ext = shortChars(base), shortChars(ext)
+	// Only ASCII is left, so the lengths are in characters.
+	alias := base[:min(len(base), 6)] + "~1"
+	if ext != "" {
+		alias += "." + ext[:min(len(ext), 3)]
+	}
+	return alias, true
+}
+
+// Reports whether s is a valid part of a short name, at most n characters long.
+func short(s string, n int) bool {
+	return s != "" && len(s) <= n && strings.IndexFunc(s, func(r rune) bool { return !shortChar(r) }) < 0
+}
+
+// Reports whether the character may appear in a short name as it is.
+func shortChar(r rune) bool {
+	return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("!#$%&'()-@^_`{}~", r)
+}
+
+// Returns the characters of s as they appear in a short name: in upper case, without
+// spaces and dots, and with an underscore for the ones short names can't hold.
+func shortChars(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == ' ' || r == '.':
+		case shortChar(r):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,175 @@
+package match
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		// Plain names and case.
+		{"readme.txt", "readme.txt", true},
+		{"README.TXT", "ReadMe.txt", true},
+		{"ÄRGER.txt", "ärger.TXT", true},
+		{"readme.txt", "readme.txt.bak", false},
+		// Stars and question marks.
+		{"*.txt", "notes.txt", true},
+		{"*.txt", "notes.txt.old", false},
+		{"*.txt", ".txt", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"*", "Makefile", true},
+		{"?.go", "a.go", true},
+		{"?.go", "ab.go", false},
+		{"??.txt", "日本.txt", true},
+		// *.* and .* match the names without an extension.
+		{"*.*", "Makefile", true},
+		{"*.*", "archive.tar.gz", true},
+		{"readme.*", "readme", true},
+		{"readme.*", "readme.md", true},
+		{"readme.*", "readme2", false},
+		// A trailing dot only matches the names without an extension.
+		{"*.", "Makefile", true},
+		{"*.", "main.go", false},
+		{"make*.", "Makefile", true},
+		// A ? at the end or before a dot may match nothing.
+		{"file?.txt", "file.txt", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file12.txt", false},
+		{"data??", "data", true},
+		{"data??", "data1", true},
+		{"data??", "data123", false},
+		// A dot in the pattern is a plain character otherwise.
+		{"*.tar.gz", "backup.tar.gz", true},
+		{"*.tar.gz", "backup.tgz", false},
+	}
+	var m Matcher
+	for _, test := range tests {
+		if have := m.Name(test.pattern, test.name); have != test.want {
+			t.Errorf("%q %q: have: %v", test.pattern, test.name, have)
+		}
+	}
+}
+
+func TestShortNames(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		long, short   bool
+	}{
+		// The short name of index.html is INDEX~1.HTM.
+		{"*.htm", "index.html", false, true},
+		{"*.xls", "budget.xlsx", false, true},
+		{"*~1*", "longfilename.txt", false, true},
+		{"*.htm", "a.htm", true, true},
+		// Names that are valid short names have no other name.
+		{"*~1*", "short.txt", false, false},
+		{"*.htm", "page.ht", false, false},
+	}
+	for _, test := range tests {
+		if have := (Matcher{}).Name(test.pattern, test.name); have != test.long {
+			t.Errorf("%q %q: have: %v", test.pattern, test.name, have)
+		}
+		if have := (Matcher{ShortNames: true}).Name(test.pattern, test.name); have != test.short {
+			t.Errorf("%q %q with short names: have: %v", test.pattern, test.name, have)
+		}
+	}
+}
+
+func TestShortName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"README.TXT", ""},
+		{"readme.txt", ""},
+		{"Makefile", ""},
+		{"index.html", "INDEX~1.HTM"},
+		{"Long File Name.docx", "LONGFI~1.DOC"},
+		{"archive.tar.gz", "ARCHIV~1.GZ"},
+		{".gitignore", "GITIGN~1"},
+		{"a+b.txt", "A_B~1.TXT"},
+		{"café.txt", "CAF_~1.TXT"},
+		{"ab.c.d", "ABC~1.D"},
+	}
+	for _, test := range tests {
+		if have, ok := ShortName(test.name); have != test.want || ok != (test.want != "") {
+			t.Errorf("%q: have: %q %v, want: %q", test.name, have, ok, test.want)
+		}
+	}
+}
+
+func TestSpec(t *testing.T) {
+	tests := []struct {
+		specs []string
+		name  string
+		want  bool
+	}{
+		{nil, "anything", true},
+		{[]string{"*.*"}, "Makefile", true},
+		{[]string{"*.jpg", "*.png"}, "photo.PNG", true},
+		{[]string{"*.jpg", "*.png"}, "photo.gif", false},
+		{[]string{"report.docx", "data?.csv"}, "data.csv", true},
+	}
+	var m Matcher
+	for _, test := range tests {
+		if have := m.Spec(test.specs, test.name); have != test.want {
+			t.Errorf("%q %q: have: %v", test.specs, test.name, have)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	src, dst := `C:\data\src\bin`, `D:\backup\bin`
+	tests := []struct {
+		patterns []string
+		want     bool
+	}{
+		// Patterns without a separator are names and match in any directory.
+		{[]string{"bin"}, true},
+		{[]string{"BIN"}, true},
+		{[]string{"b?n", "obj"}, true},
+		{[]string{"obj"}, false},
+		// Patterns with a separator are full paths, of the source or the destination.
+		{[]string{`C:\data\src\bin`}, true},
+		{[]string{`c:/data/src/bin/`}, true},
+		{[]string{`D:\backup\bin\`}, true},
+		{[]string{`C:\data\*\bin`}, true},
+		{[]string{`*\bin`}, true},
+		{[]string{`C:\data\src`}, false},
+		// A relative path is no name and matches no full path.
+		{[]string{`src\bin`}, false},
+	}
+	var m Matcher
+	for _, test := range tests {
+		if have := m.Excluded(test.patterns, "bin", src, dst); have != test.want {
+			t.Errorf("%q: have: %v", test.patterns, have)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/home/me/src/tmp", "/home/me/src/tmp", true},
+		{"/home/me/src/tmp/", "/home/me/src/tmp", true},
+		{`\\server\share\logs`, `\\SERVER\share\logs\`, true},
+		{"/home/*/tmp", "/home/me/src/tmp", true},
+		{"/home/me/src", "/home/me/src/tmp", false},
+	}
+	for _, test := range tests {
+		if have := Path(test.pattern, test.path); have != test.want {
+			t.Errorf("%q %q: have: %v", test.pattern, test.path, have)
+		}
+	}
+}
+
+func TestManyStars(t *testing.T) {
+	pattern, name := strings.Repeat("*a", 50)+"b", strings.Repeat("a", 5000)
+	if (Matcher{}).Name(pattern, name) {
+		t.Error("matched")
+	}
+}
@@ -0,0 +1,209 @@
+// Package metrics exports the progress and results of robocopy jobs as Prometheus
+// metrics. A Collector is fed the events of jobs started with Robocopy.Start, or of
+// recorded output read with gorobocopy.Stream, and is registered with a Prometheus
+// registry like any other collector:
+//
+//	c := metrics.NewCollector()
+//	prometheus.MustRegister(c)
+//	job, err := cmd.Start(ctx, gorobocopy.StreamOptions{})
+//	...
+//	result, err := c.Follow("nightly", job)
+//
+// All metrics are labeled with the job name. The summary counts are added up over the
+// runs of a job, the other metrics describe its last or current run.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/output"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "robocopy"
+
+// The columns of the summary table, as status label values.
+var statuses = []string{"total", "copied", "skipped", "mismatch", "failed", "extras"}
+
+// The exit code bits, as bit label values.
+var bits = []struct {
+	code gorobocopy.ExitCode
+	name string
+}{
+	{gorobocopy.FilesCopied, "copied"},
+	{gorobocopy.ExtrasDetected, "extras"},
+	{gorobocopy.MismatchesDetected, "mismatches"},
+	{gorobocopy.CopyFailures, "failures"},
+	{gorobocopy.FatalError, "fatal"},
+}
+
+var (
+	dirsDesc       = desc("dirs_total", "Directories by summary column, added up over the runs.", "status")
+	filesDesc      = desc("files_total", "Files by summary column, added up over the runs.", "status")
+	bytesDesc      = desc("bytes_total", "Bytes by summary column, added up over the runs.", "status")
+	runsDesc       = desc("runs_total", "Finished runs.")
+	retriesDesc    = desc("retries_total", "Retries after failed copies.")
+	errorsDesc     = desc("errors_total", "Errors reported while copying.")
+	runningDesc    = desc("running", "Whether a run is in progress.")
+	durationDesc   = desc("duration_seconds", "Duration of the last finished run.")
+	completionDesc = desc("last_completion_timestamp_seconds", "When the last run finished, as a Unix time.")
+	throughputDesc = desc("throughput_bytes_per_second", "Throughput of the current run over the last second.")
+	progressDesc   = desc("progress_bytes", "Bytes copied by the current run so far.")
+	exitCodeDesc   = desc("exit_code", "Exit code of the last finished run.")
+	exitBitDesc    = desc("exit_code_bit", "Whether a bit of the exit code of the last finished run is set.", "bit")
+)
+
+func desc(name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, append([]string{"job"}, labels...), nil)
+}
+
+// The metrics of one job.
+type job struct {
+	dirs, files, bytes [6]float64 // by status
+	runs               float64
+	retries            float64
+	errors             float64
+	running            bool
+	finished           bool // has a last run
+	duration           time.Duration
+	completion         time.Time
+	throughput         float64
+	progress           float64
+	exitCode           gorobocopy.ExitCode
+}
+
+// Collector is a prometheus.Collector for robocopy jobs. It is safe for concurrent use.
+type Collector struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+	now  func() time.Time
+}
+
+// NewCollector returns a collector without any jobs.
+func NewCollector() *Collector {
+	return &Collector{jobs: map[string]*job{}, now: time.Now}
+}
+
+// Returns the metrics of the named job, creating them if needed. The caller holds the lock.
+func (c *Collector) job(name string) *job {
+	j, ok := c.jobs[name]
+	if !ok {
+		j = &job{}
+		c.jobs[name] = j
+	}
+	return j
+}
+
+// Observe updates the metrics of the named job with an event of one of its runs.
+func (c *Collector) Observe(name string, event gorobocopy.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	j := c.job(name)
+	if event.Result != nil || event.Err != nil {
+		j.running, j.throughput, j.progress = false, 0, 0
+		if event.Result != nil {
+			c.finish(j, event.Result)
+		}
+		return
+	}
+	j.running = true
+	j.throughput = event.Progress.BytesPerSecond
+	j.progress = float64(event.Progress.BytesDone)
+	switch e := event.Output.(type) {
+	case output.RetryEvent:
+		j.retries++
+	case output.ErrorEvent:
+		if e.Code != 0 {
+			j.errors++
+		}
+	}
+}
+
+// ObserveResult updates the metrics of the named job with a run that finished without
+// being observed, e.g. one run with RunContext.
+func (c *Collector) ObserveResult(name string, result *gorobocopy.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finish(c.job(name), result)
+}
+
+func (c *Collector) finish(j *job, result *gorobocopy.Result) {
+	j.runs++
+	j.finished = true
+	j.duration = result.Duration
+	j.completion = c.now()
+	j.exitCode = result.ExitCode
+	if s := result.Summary; s != nil {
+		add(&j.dirs, s.Dirs)
+		add(&j.files, s.Files)
+		add(&j.bytes, s.Bytes)
+	}
+}
+
+// Adds a row of the summary table to the counts by status.
+func add(counts *[6]float64, row output.Counts) {
+	for i, n := range []int64{row.Total, row.Copied, row.Skipped, row.Mismatch, row.Failed, row.Extras} {
+		counts[i] += float64(n)
+	}
+}
+
+// Follow observes the events of a job until it is finished, and returns its result.
+func (c *Collector) Follow(name string, job *gorobocopy.Job) (*gorobocopy.Result, error) {
+	for event := range job.Events() {
+		c.Observe(name, event)
+	}
+	return job.Wait()
+}
+
+// Forget removes the metrics of the named job.
+func (c *Collector) Forget(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.jobs, name)
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{dirsDesc, filesDesc, bytesDesc, runsDesc, retriesDesc, errorsDesc, runningDesc, durationDesc, completionDesc, throughputDesc, progressDesc, exitCodeDesc, exitBitDesc} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, j := range c.jobs {
+		for i, status := range statuses {
+			ch <- prometheus.MustNewConstMetric(dirsDesc, prometheus.CounterValue, j.dirs[i], name, status)
+			ch <- prometheus.MustNewConstMetric(filesDesc, prometheus.CounterValue, j.files[i], name, status)
+			ch <- prometheus.MustNewConstMetric(bytesDesc, prometheus.CounterValue, j.bytes[i], name, status)
+		}
+		ch <- prometheus.MustNewConstMetric(runsDesc, prometheus.CounterValue, j.runs, name)
+		ch <- prometheus.MustNewConstMetric(retriesDesc, prometheus.CounterValue, j.retries, name)
+		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.CounterValue, j.errors, name)
+		ch <- prometheus.MustNewConstMetric(runningDesc, prometheus.GaugeValue, boolValue(j.running), name)
+		ch <- prometheus.MustNewConstMetric(throughputDesc, prometheus.GaugeValue, j.throughput, name)
+		ch <- prometheus.MustNewConstMetric(progressDesc, prometheus.GaugeValue, j.progress, name)
+		if !j.finished {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(durationDesc, prometheus.GaugeValue, j.duration.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(completionDesc, prometheus.GaugeValue, float64(j.completion.UnixNano())/1e9, name)
+		ch <- prometheus.MustNewConstMetric(exitCodeDesc, prometheus.GaugeValue, float64(j.exitCode), name)
+		// A stopped run has the exit code -1, which isn't a combination of bits.
+		valid := j.exitCode >= 0 && j.exitCode <= 31
+		for _, bit := range bits {
+			ch <- prometheus.MustNewConstMetric(exitBitDesc, prometheus.GaugeValue, boolValue(valid && j.exitCode&bit.code != 0), name, bit.name)
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/output"
+	"github.com/aggellos2001/go-robocopy/robocopytest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const log = "\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"   ROBOCOPY     ::     Robust File Copy for Windows\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"  Started : Monday, January 1, 2024 10:00:00 AM\r\n" +
+	"   Source : C:\\source\\\r\n" +
+	"     Dest : D:\\dest\\\r\n" +
+	"\r\n" +
+	"    Files : *.*\r\n" +
+	"\r\n" +
+	"  Options : *.* /S /E /DCOPY:DA /COPY:DAT /R:1 /W:5\r\n" +
+	"\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"\t                   2\tC:\\source\\\r\n" +
+	"\t    New File  \t\t     100\ta.txt\r\n" +
+	"100%  \r\n" +
+	"\t    New File  \t\t    1000\tlocked.txt\r\n" +
+	"2024/01/01 10:00:00 ERROR 32 (0x00000020) Copying File C:\\source\\locked.txt\r\n" +
+	"The process cannot access the file because it is being used by another process.\r\n" +
+	"Waiting 5 seconds... Retrying...\r\n" +
+	"\t    New File  \t\t    1000\tlocked.txt\r\n" +
+	"2024/01/01 10:00:05 ERROR 32 (0x00000020) Copying File C:\\source\\locked.txt\r\n" +
+	"The process cannot access the file because it is being used by another process.\r\n" +
+	"\r\n" +
+	"ERROR: RETRY LIMIT EXCEEDED.\r\n" +
+	"\r\n" +
+	"\t  *EXTRA File \t\t      20\told.txt\r\n" +
+	"\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"               Total    Copied   Skipped  Mismatch    FAILED    Extras\r\n" +
+	"    Dirs :         1         0         1         0         0         0\r\n" +
+	"   Files :         2         1         0         0         1         1\r\n" +
+	"   Bytes :      1100       100         0         0      1000        20\r\n" +
+	"   Times :   0:00:05   0:00:00                       0:00:05   0:00:00\r\n" +
+	"   Ended : Monday, January 1, 2024 10:00:05 AM\r\n"
+
+func newTestCollector() *Collector {
+	c := NewCollector()
+	c.now = func() time.Time { return time.Unix(1704103205, 0) }
+	return c
+}
+
+func TestFollow(t *testing.T) {
+	c := newTestCollector()
+	for i := 0; i < 2; i++ {
+		if _, err := c.Follow("nightly", gorobocopy.Stream(strings.NewReader(log), gorobocopy.StreamOptions{})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+	want := `
+# HELP robocopy_files_total Files by summary column, added up over the runs.
+# TYPE robocopy_files_total counter
+robocopy_files_total{job="nightly",status="copied"} 2
+robocopy_files_total{job="nightly",status="extras"} 2
+robocopy_files_total{job="nightly",status="failed"} 2
+robocopy_files_total{job="nightly",status="mismatch"} 0
+robocopy_files_total{job="nightly",status="skipped"} 0
+robocopy_files_total{job="nightly",status="total"} 4
+# HELP robocopy_bytes_total Bytes by summary column, added up over the runs.
+# TYPE robocopy_bytes_total counter
+robocopy_bytes_total{job="nightly",status="copied"} 200
+robocopy_bytes_total{job="nightly",status="extras"} 40
+robocopy_bytes_total{job="nightly",status="failed"} 2000
+robocopy_bytes_total{job="nightly",status="mismatch"} 0
+robocopy_bytes_total{job="nightly",status="skipped"} 0
+robocopy_bytes_total{job="nightly",status="total"} 2200
+# HELP robocopy_retries_total Retries after failed copies.
+# TYPE robocopy_retries_total counter
+robocopy_retries_total{job="nightly"} 2
+# HELP robocopy_errors_total Errors reported while copying.
+# TYPE robocopy_errors_total counter
+robocopy_errors_total{job="nightly"} 4
+# HELP robocopy_runs_total Finished runs.
+# TYPE robocopy_runs_total counter
+robocopy_runs_total{job="nightly"} 2
+# HELP robocopy_running Whether a run is in progress.
+# TYPE robocopy_running gauge
+robocopy_running{job="nightly"} 0
+# HELP robocopy_exit_code Exit code of the last finished run.
+# TYPE robocopy_exit_code gauge
+robocopy_exit_code{job="nightly"} 11
+# HELP robocopy_exit_code_bit Whether a bit of the exit code of the last finished run is set.
+# TYPE robocopy_exit_code_bit gauge
+robocopy_exit_code_bit{bit="copied",job="nightly"} 1
+robocopy_exit_code_bit{bit="extras",job="nightly"} 1
+robocopy_exit_code_bit{bit="failures",job="nightly"} 1
+robocopy_exit_code_bit{bit="fatal",job="nightly"} 0
+robocopy_exit_code_bit{bit="mismatches",job="nightly"} 0
+# HELP robocopy_last_completion_timestamp_seconds When the last run finished, as a Unix time.
+# TYPE robocopy_last_completion_timestamp_seconds gauge
+robocopy_last_completion_timestamp_seconds{job="nightly"} 1.704103205e+09
+`
+	names := []string{"robocopy_files_total", "robocopy_bytes_total", "robocopy_retries_total", "robocopy_errors_total", "robocopy_runs_total",
+		"robocopy_running", "robocopy_exit_code", "robocopy_exit_code_bit", "robocopy_last_completion_timestamp_seconds"}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), names...); err != nil {
+		t.Error(err)
+	}
+	if n, err := testutil.GatherAndCount(reg); err != nil || n != 32 {
+		t.Errorf("have: %d metrics, %v", n, err)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	c := newTestCollector()
+	c.Observe("a", gorobocopy.Event{
+		Output:   output.ProgressEvent{Percent: 50},
+		Progress: gorobocopy.Progress{BytesDone: 4096, BytesPerSecond: 2048},
+	})
+	c.ObserveResult("b", &gorobocopy.Result{ExitCode: gorobocopy.FatalError, Duration: 90 * time.Second})
+	want := `
+# HELP robocopy_running Whether a run is in progress.
+# TYPE robocopy_running gauge
+robocopy_running{job="a"} 1
+robocopy_running{job="b"} 0
+# HELP robocopy_throughput_bytes_per_second Throughput of the current run over the last second.
+# TYPE robocopy_throughput_bytes_per_second gauge
+robocopy_throughput_bytes_per_second{job="a"} 2048
+robocopy_throughput_bytes_per_second{job="b"} 0
+# HELP robocopy_progress_bytes Bytes copied by the current run so far.
+# TYPE robocopy_progress_bytes gauge
+robocopy_progress_bytes{job="a"} 4096
+robocopy_progress_bytes{job="b"} 0
+# HELP robocopy_duration_seconds Duration of the last finished run.
+# TYPE robocopy_duration_seconds gauge
+robocopy_duration_seconds{job="b"} 90
+# HELP robocopy_exit_code Exit code of the last finished run.
+# TYPE robocopy_exit_code gauge
+robocopy_exit_code{job="b"} 16
+`
+	names := []string{"robocopy_running", "robocopy_throughput_bytes_per_second", "robocopy_progress_bytes", "robocopy_duration_seconds", "robocopy_exit_code"}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), names...); err != nil {
+		t.Error(err)
+	}
+
+	c.Forget("a")
+	if n := testutil.CollectAndCount(c, "robocopy_running"); n != 1 {
+		t.Errorf("have: %d jobs after Forget", n)
+	}
+}
+
+func TestFollowCanceled(t *testing.T) {
+	r := gorobocopy.NewRobocopy(`C:\source`, `D:\dest`, "")
+	r.SetExecutor(robocopytest.New(robocopytest.Response{Delay: time.Minute}))
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := r.Start(ctx, gorobocopy.StreamOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	c := newTestCollector()
+	if result, _ := c.Follow("nightly", job); result == nil || result.ExitCode != -1 {
+		t.Fatalf("have: %+v", result)
+	}
+	want := `
+# HELP robocopy_exit_code Exit code of the last finished run.
+# TYPE robocopy_exit_code gauge
+robocopy_exit_code{job="nightly"} -1
+# HELP robocopy_exit_code_bit Whether a bit of the exit code of the last finished run is set.
+# TYPE robocopy_exit_code_bit gauge
+robocopy_exit_code_bit{bit="copied",job="nightly"} 0
+robocopy_exit_code_bit{bit="extras",job="nightly"} 0
+robocopy_exit_code_bit{bit="failures",job="nightly"} 0
+robocopy_exit_code_bit{bit="fatal",job="nightly"} 0
+robocopy_exit_code_bit{bit="mismatches",job="nightly"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "robocopy_exit_code", "robocopy_exit_code_bit"); err != nil {
+		t.Error(err)
+	}
+}
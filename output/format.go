@@ -0,0 +1,149 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatter writes events as text in the layout robocopy uses, so that the output can be
+// read back with the Parser and looks familiar in logs.
+type Formatter struct {
+	W     io.Writer
+	Bytes bool // Print sizes as bytes (/bytes) instead of scaled values such as 1.5 m.
+}
+
+const separator = "------------------------------------------------------------------------------"
+
+// Format writes a single event.
+func (f *Formatter) Format(event Event) error {
+	var b strings.Builder
+	switch e := event.(type) {
+	case HeaderEvent:
+		fmt.Fprintf(&b, "\r\n%s\r\n   ROBOCOPY     ::     Robust File Copy\r\n%s\r\n\r\n", separator, separator)
+		fmt.Fprintf(&b, "  Started : %s\r\n   Source : %s\r\n     Dest : %s\r\n\r\n", e.Started, e.Source, e.Destination)
+		writeList(&b, "    Files :", e.Files)
+		writeList(&b, "Exc Files :", e.ExcludedFiles)
+		writeList(&b, " Exc Dirs :", e.ExcludedDirs)
+		fmt.Fprintf(&b, "  Options : %s\r\n\r\n%s\r\n\r\n", e.Options, separator)
+	case DirEvent:
+		fmt.Fprintf(&b, "\t%s%10d\t%s\r\n", padClass(e.Class, 10), e.Files, e.Path)
+	case FileEvent:
+		fmt.Fprintf(&b, "\t%s\t", padClass(e.Class, 14))
+		if e.Size >= 0 {
+			fmt.Fprintf(&b, "\t%8s", f.size(e.Size))
+		}
+		if !e.Time.IsZero() {
+			fmt.Fprintf(&b, "\t%s", e.Time.Format(timeLayout))
+		}
+		fmt.Fprintf(&b, "\t%s\r\n", e.Name)
+	case ProgressEvent:
+		fmt.Fprintf(&b, "%5s%%  \r", strconv.FormatFloat(e.Percent, 'f', -1, 64))
+		if e.Percent >= 100 {
+			b.WriteString("\n")
+		}
+	case ErrorEvent:
+		if e.Code == 0 && e.Action == "" {
+			fmt.Fprintf(&b, "\r\nERROR: %s\r\n\r\n", e.Message)
+			break
+		}
+		if !e.Time.IsZero() {
+			b.WriteString(e.Time.Format(timeLayout) + " ")
+		}
+		fmt.Fprintf(&b, "ERROR %d (0x%08X) %s %s\r\n%s\r\n", e.Code, e.Code, e.Action, e.Path, e.Message)
+	case RetryEvent:
+		fmt.Fprintf(&b, "Waiting %d seconds... Retrying...\r\n", int(e.Wait/time.Second))
+	case SummaryEvent:
+		if e.Summary == nil {
+			for _, line := range e.Lines {
+				b.WriteString(line + "\r\n")
+			}
+			break
+		}
+		f.summary(&b, e.Summary)
+	}
+	_, err := io.WriteString(f.W, b.String())
+	return err
+}
+
+func writeList(b *strings.Builder, key string, values []string) {
+	for i, value := range values {
+		if i == 0 {
+			fmt.Fprintf(b, "%s %s\r\n", key, value)
+		} else {
+			fmt.Fprintf(b, "\t    \t    %s\r\n", value)
+		}
+	}
+	if len(values) != 0 {
+		b.WriteString("\r\n")
+	}
+}
+
+// Pads the class the way robocopy does: extra and mismatch classes start with an
+// asterisk two columns further left than the others.
+func padClass(class string, width int) string {
+	if class == "" {
+		return strings.Repeat(" ", width)
+	}
+	if strings.HasPrefix(class, "*") {
+		return fmt.Sprintf("  %-*s", width-2, class)
+	}
+	return fmt.Sprintf("    %-*s", width-4, class)
+}
+
+func (f *Formatter) size(n int64) string {
+	if f.Bytes || n < 1<<10 {
+		return strconv.FormatInt(n, 10)
+	}
+	return scaled(n, 1)
+}
+
+// Formats the size with one of the k, m, g or t suffixes.
+func scaled(n int64, precision int) string {
+	value, unit := float64(n), ""
+	for _, u := range []string{"k", "m", "g", "t"} {
+		if value < 1<<10 {
+			break
+		}
+		value /= 1 << 10
+		unit = u
+	}
+	return strconv.FormatFloat(value, 'f', precision, 64) + " " + unit
+}
+
+func (f *Formatter) summary(b *strings.Builder, s *Summary) {
+	fmt.Fprintf(b, "\r\n%s\r\n\r\n", separator)
+	fmt.Fprintf(b, "%10s%10s%10s%10s%10s%10s%10s\r\n", "", "Total", "Copied", "Skipped", "Mismatch", "FAILED", "Extras")
+	row := func(name string, c Counts, format func(int64) string) {
+		fmt.Fprintf(b, "%8s :%10s%10s%10s%10s%10s%10s\r\n", name,
+			format(c.Total), format(c.Copied), format(c.Skipped), format(c.Mismatch), format(c.Failed), format(c.Extras))
+	}
+	count := func(n int64) string {
+		return strconv.FormatInt(n, 10)
+	}
+	bytes := func(n int64) string {
+		if f.Bytes || n < 1<<10 {
+			return count(n)
+		}
+		return scaled(n, 2)
+	}
+	row("Dirs", s.Dirs, count)
+	row("Files", s.Files, count)
+	row("Bytes", s.Bytes, bytes)
+	fmt.Fprintf(b, "%8s :%10s%10s%20s%10s%10s\r\n\r\n", "Times",
+		duration(s.Times.Total), duration(s.Times.Copied), "", duration(s.Times.Failed), duration(s.Times.Extras))
+	if s.BytesPerSecond != 0 || s.MegabytesPerMinute != 0 {
+		fmt.Fprintf(b, "\r\n%8s :%20.0f Bytes/sec.\r\n", "Speed", s.BytesPerSecond)
+		fmt.Fprintf(b, "%8s :%20.3f MegaBytes/min.\r\n", "Speed", s.MegabytesPerMinute)
+	}
+	if s.Ended != "" {
+		fmt.Fprintf(b, "%8s : %s\r\n", "Ended", s.Ended)
+	}
+}
+
+func duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Formatted events must parse back into the same events.
+func TestFormatRoundTrip(t *testing.T) {
+	stamp := time.Date(2024, 1, 1, 10, 0, 5, 0, time.Local)
+	summary := &Summary{
+		Dirs:  Counts{Total: 2, Copied: 1, Skipped: 1, Extras: 1},
+		Files: Counts{Total: 3, Copied: 2, Skipped: 1, Failed: 1, Extras: 1},
+		Bytes: Counts{Total: 3 << 20, Copied: 1 << 20, Skipped: 2 << 20, Extras: 20},
+		Times: Times{Total: 65 * time.Second, Copied: 3 * time.Second},
+		Ended: "Monday, January 1, 2024 10:01:05 AM",
+	}
+	events := []Event{
+		HeaderEvent{
+			Started:       "Monday, January 1, 2024 10:00:00 AM",
+			Source:        "C:\\source\\",
+			Destination:   "D:\\dest\\",
+			Files:         []string{"*.docx", "*.xlsx"},
+			ExcludedFiles: []string{"*.tmp"},
+			Options:       "*.docx *.xlsx /E /R:3 /W:5",
+		},
+		DirEvent{Files: 2, Path: "C:\\source\\"},
+		FileEvent{Class: "New File", Size: 100, Name: "a.docx", Path: "C:\\source\\a.docx"},
+		ProgressEvent{Percent: 0, Path: "C:\\source\\a.docx"},
+		ProgressEvent{Percent: 12.5, Path: "C:\\source\\a.docx"},
+		ProgressEvent{Percent: 100, Path: "C:\\source\\a.docx"},
+		FileEvent{Class: "*EXTRA File", Size: 20, Time: stamp, Name: "old.docx", Path: "D:\\dest\\old.docx"},
+		DirEvent{Class: "New Dir", Files: 1, Path: "C:\\source\\sub\\"},
+		FileEvent{Class: "Newer", Size: 1536, Name: "b.docx", Path: "C:\\source\\sub\\b.docx"},
+		ErrorEvent{Time: stamp, Code: 5, Action: "Copying File", Path: "C:\\source\\sub\\b.docx", Message: "Access is denied."},
+		RetryEvent{Wait: 5 * time.Second},
+		ErrorEvent{Message: "RETRY LIMIT EXCEEDED."},
+		DirEvent{Class: "*EXTRA Dir", Files: -1, Path: "D:\\dest\\gone\\"},
+		SummaryEvent{Summary: summary},
+	}
+	var b strings.Builder
+	f := &Formatter{W: &b}
+	for _, event := range events {
+		if err := f.Format(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	have, err := Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(have); n == 0 || reflect.TypeOf(have[n-1]) != reflect.TypeOf(SummaryEvent{}) {
+		t.Fatalf("no summary in:\n%s", b.String())
+	}
+	if parsed := have[len(have)-1].(SummaryEvent).Summary; !reflect.DeepEqual(summary, parsed) {
+		t.Errorf("summary:\nhave: %+v\nwant: %+v", parsed, summary)
+	}
+	have[len(have)-1] = events[len(events)-1]
+	if !reflect.DeepEqual(events, have) {
+		t.Errorf("have: %+v\nwant: %+v\noutput:\n%s", have, events, b.String())
+	}
+}
+
+func TestFormatSizes(t *testing.T) {
+	tests := []struct {
+		size  int64
+		bytes bool
+		want  string
+	}{
+		{100, false, "     100"},
+		{1536, false, "   1.5 k"},
+		{3 << 30, false, "   3.0 g"},
+		{1536, true, "    1536"},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		(&Formatter{W: &b, Bytes: test.bytes}).Format(FileEvent{Class: "New File", Size: test.size, Name: "a"})
+		if want := "\t    New File  \t\t" + test.want + "\ta\r\n"; b.String() != want {
+			t.Errorf("%d: have: %q, want: %q", test.size, b.String(), want)
+		}
+	}
+}
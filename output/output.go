@@ -0,0 +1,388 @@
+// Package output parses the console and log output of robocopy into typed events.
+//
+// The parser detects the column layout of every line on its own, so output produced
+// with any combination of the /fp, /ts, /bytes, /ns, /nc, /njh and /njs logging
+// options is handled without any configuration. Progress percentages, which robocopy
+// separates with carriage returns, are reported as their own events.
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is implemented by all the event types produced by the Parser:
+// HeaderEvent, DirEvent, FileEvent, ProgressEvent, ErrorEvent, RetryEvent and SummaryEvent.
+type Event interface {
+	event()
+}
+
+// HeaderEvent is the job header printed before anything is copied (not printed with /njh).
+type HeaderEvent struct {
+	Started       string   // Start time exactly as printed, the format depends on the system locale.
+	Source        string   // Source directory.
+	Destination   string   // Destination directory.
+	Files         []string // File specifications.
+	ExcludedFiles []string // Names given to /xf.
+	ExcludedDirs  []string // Names given to /xd.
+	Options       string   // The options robocopy ended up using.
+}
+
+// DirEvent is printed when robocopy starts processing a directory (not printed with /ndl).
+type DirEvent struct {
+	Class string // Empty for existing directories, otherwise e.g. New Dir or *EXTRA Dir.
+	Files int64  // Number of files in the directory, -1 for extra directories.
+	Path  string // Full path of the directory, always ending with a path separator.
+}
+
+// FileEvent is printed for every file robocopy selects or, with /v or /x, skips (not printed with /nfl).
+type FileEvent struct {
+	Class string    // File class such as New File, Newer, *EXTRA File or same, lowercase for skipped files. Empty with /nc.
+	Size  int64     // File size in bytes, approximated unless /bytes was used. -1 with /ns.
+	Time  time.Time // Source time stamp, only set with /ts.
+	Name  string    // Name exactly as printed, the full path with /fp.
+	Path  string    // Full path of the file, in the destination for extra files.
+}
+
+// ProgressEvent is the percentage of the current file that has been copied (not printed with /np).
+type ProgressEvent struct {
+	Percent float64
+	Path    string // Full path of the file being copied.
+}
+
+// ErrorEvent is an error robocopy reported while processing a file or directory.
+type ErrorEvent struct {
+	Time    time.Time // When the error happened. Zero for errors without a time stamp.
+	Code    int       // Windows error code, 0 if robocopy didn't print one.
+	Action  string    // What robocopy was doing, e.g. Copying File.
+	Path    string    // The file or directory the action was performed on.
+	Message string    // Description of the error.
+}
+
+// RetryEvent is printed when robocopy waits before retrying a failed operation.
+type RetryEvent struct {
+	Wait time.Duration
+}
+
+// SummaryEvent is the job summary printed at the end of a run (not printed with /njs).
+type SummaryEvent struct {
+	Summary *Summary // The parsed summary table, nil if it couldn't be parsed.
+	Lines   []string // The lines of the summary block.
+	Err     error    // A speed that couldn't be parsed, which is left out of Summary.
+}
+
+func (HeaderEvent) event()   {}
+func (DirEvent) event()      {}
+func (FileEvent) event()     {}
+func (ProgressEvent) event() {}
+func (ErrorEvent) event()    {}
+func (RetryEvent) event()    {}
+func (SummaryEvent) event()  {}
+
+const timeLayout = "2006/01/02 15:04:05"
+
+var (
+	separatorPattern = regexp.MustCompile(`^-{20,}$`)
+	titlePattern     = regexp.MustCompile(`^ROBOCOPY\s+::`)
+	headerPattern    = regexp.MustCompile(`^\s*(Started|Source|Dest|Files|Exc Files|Exc Dirs|Options)\s*:\s?(.*)$`)
+	progressPattern  = regexp.MustCompile(`^(\d+(?:\.\d+)?)%$`)
+	errorPattern     = regexp.MustCompile(`^(?:(\d{4}/\d\d/\d\d \d\d:\d\d:\d\d) )?ERROR (\d+) \(0x[0-9A-Fa-f]+\) (.*)$`)
+	retryPattern     = regexp.MustCompile(`^Waiting (\d+) seconds\.*\s*Retrying`)
+	dirPattern       = regexp.MustCompile(`^(.*?)\s*(-?\d+)$`)
+	sizePattern      = regexp.MustCompile(`^(\d+(?:\.\d+)?)(?: ([kmgt]))?$`)
+	timePattern      = regexp.MustCompile(`^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d$`)
+	pathStartPattern = regexp.MustCompile(`[A-Za-z]:\\|\\\\|/`)
+)
+
+type state int
+
+const (
+	inBody state = iota
+	afterSeparator
+	inHeader
+	inSummary
+)
+
+// Parser reads robocopy output line by line and turns it into events.
+type Parser struct {
+	scanner *bufio.Scanner
+	state   state
+	header  *HeaderEvent
+	source  string // source directory of the current job
+	dest    string // destination directory of the current job
+	lastKey string
+	summary []string
+	pending *ErrorEvent // error waiting for its message line
+	dir     string      // current directory
+	file    string      // current file
+	queue   []Event
+}
+
+// NewParser returns a parser reading robocopy output from r.
+func NewParser(r io.Reader) *Parser {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	scanner.Split(scanLines)
+	return &Parser{scanner: scanner}
+}
+
+// Parse reads all of r and returns the events found in it.
+func Parse(r io.Reader) (events []Event, err error) {
+	p := NewParser(r)
+	for {
+		event, err := p.Next()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+// Next returns the next event. It returns io.EOF once the output is exhausted.
+func (p *Parser) Next() (Event, error) {
+	for len(p.queue) == 0 {
+		if !p.scanner.Scan() {
+			if err := p.scanner.Err(); err != nil {
+				return nil, err
+			}
+			p.flush()
+			if len(p.queue) == 0 {
+				return nil, io.EOF
+			}
+			break
+		}
+		p.line(p.scanner.Text())
+	}
+	event := p.queue[0]
+	p.queue = p.queue[1:]
+	return event, nil
+}
+
+// Splits on \n, \r or \r\n so that progress updates are returned as separate lines.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' && i+1 == len(data) && !atEOF {
+			return 0, nil, nil // need to know whether \n follows
+		}
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (p *Parser) emit(event Event) {
+	p.queue = append(p.queue, event)
+}
+
+// Emits the events that are still being assembled.
+func (p *Parser) flush() {
+	if p.pending != nil {
+		p.emit(*p.pending)
+		p.pending = nil
+	}
+	if p.header != nil {
+		p.source, p.dest = p.header.Source, p.header.Destination
+		p.emit(*p.header)
+		p.header = nil
+	}
+	if len(p.summary) != 0 {
+		summary, err := parseSummaryLines(p.summary)
+		p.emit(SummaryEvent{Summary: summary, Lines: p.summary, Err: err})
+		p.summary = nil
+	}
+}
+
+func (p *Parser) line(line string) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return
+	}
+	if titlePattern.MatchString(trimmed) {
+		p.flush()
+		p.header = &HeaderEvent{}
+		p.lastKey = ""
+		p.state = inHeader
+		return
+	}
+	if separatorPattern.MatchString(trimmed) {
+		switch p.state {
+		case inHeader:
+			// The header starts and ends with a separator.
+			if p.lastKey != "" {
+				p.flush()
+				p.state = inBody
+			}
+		case inBody:
+			p.flush()
+			p.state = afterSeparator
+		}
+		return
+	}
+
+	switch p.state {
+	case inHeader:
+		p.headerLine(trimmed)
+		return
+	case afterSeparator:
+		p.state = inSummary
+	}
+	if p.state == inSummary {
+		p.summary = append(p.summary, line)
+		if strings.HasPrefix(trimmed, "Ended :") {
+			p.flush()
+			p.state = inBody
+		}
+		return
+	}
+	p.bodyLine(line, trimmed)
+}
+
+func (p *Parser) headerLine(line string) {
+	h := p.header
+	key, value := p.lastKey, line
+	if m := headerPattern.FindStringSubmatch(line); m != nil {
+		key, value = m[1], strings.TrimSpace(m[2])
+		p.lastKey = key
+	}
+	switch key {
+	case "Started":
+		h.Started = value
+	case "Source":
+		h.Source = value
+	case "Dest":
+		h.Destination = value
+	case "Files":
+		h.Files = appendNonEmpty(h.Files, value)
+	case "Exc Files":
+		h.ExcludedFiles = appendNonEmpty(h.ExcludedFiles, value)
+	case "Exc Dirs":
+		h.ExcludedDirs = appendNonEmpty(h.ExcludedDirs, value)
+	case "Options":
+		h.Options = strings.TrimSpace(h.Options + " " + value)
+	}
+}
+
+func appendNonEmpty(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	return append(list, value)
+}
+
+func (p *Parser) bodyLine(line, trimmed string) {
+	if m := progressPattern.FindStringSubmatch(trimmed); m != nil {
+		percent, _ := strconv.ParseFloat(m[1], 64)
+		p.emit(ProgressEvent{Percent: percent, Path: p.file})
+		return
+	}
+	if p.pending != nil {
+		if !strings.HasPrefix(line, "\t") && !errorPattern.MatchString(trimmed) && !retryPattern.MatchString(trimmed) {
+			p.pending.Message = trimmed
+			p.flush()
+			return
+		}
+		p.flush()
+	}
+	if m := errorPattern.FindStringSubmatch(trimmed); m != nil {
+		event := &ErrorEvent{}
+		if m[1] != "" {
+			event.Time, _ = time.ParseInLocation(timeLayout, m[1], time.Local)
+		}
+		event.Code, _ = strconv.Atoi(m[2])
+		event.Action = m[3]
+		if loc := pathStartPattern.FindStringIndex(m[3]); loc != nil {
+			event.Action = strings.TrimSpace(m[3][:loc[0]])
+			event.Path = m[3][loc[0]:]
+		}
+		p.pending = event
+		return
+	}
+	if m := retryPattern.FindStringSubmatch(trimmed); m != nil {
+		seconds, _ := strconv.Atoi(m[1])
+		p.emit(RetryEvent{Wait: time.Duration(seconds) * time.Second})
+		return
+	}
+	if strings.HasPrefix(trimmed, "ERROR:") {
+		p.emit(ErrorEvent{Message: strings.TrimSpace(strings.TrimPrefix(trimmed, "ERROR:"))})
+		return
+	}
+	if !strings.HasPrefix(line, "\t") {
+		return // nothing we know about
+	}
+
+	fields := strings.Split(line[1:], "\t")
+	name := fields[len(fields)-1]
+	columns := fields[:len(fields)-1]
+	if strings.HasSuffix(name, `\`) || strings.HasSuffix(name, "/") {
+		// Directory paths always end with a separator, file names never do.
+		m := dirPattern.FindStringSubmatch(strings.TrimSpace(strings.Join(columns, " ")))
+		if m == nil {
+			return
+		}
+		count, _ := strconv.ParseInt(m[2], 10, 64)
+		p.dir = name
+		p.emit(DirEvent{Class: m[1], Files: count, Path: name})
+		return
+	}
+
+	event := FileEvent{Size: -1, Name: name, Path: name}
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+		switch {
+		case column == "":
+		case timePattern.MatchString(column):
+			event.Time, _ = time.ParseInLocation(timeLayout, column, time.Local)
+		case sizePattern.MatchString(column):
+			event.Size = parseSize(column)
+		default:
+			event.Class = column
+		}
+	}
+	if !isAbs(name) {
+		event.Path = p.dir + name
+		// Extra files are listed under the source directory but live in the destination.
+		if strings.HasPrefix(event.Class, "*EXTRA") && p.dest != "" && strings.HasPrefix(p.dir, p.source) {
+			event.Path = p.dest + p.dir[len(p.source):] + name
+		}
+	}
+	p.file = event.Path
+	p.emit(event)
+}
+
+// Parses a size as printed by robocopy, either plain bytes or scaled such as 1.5 m.
+func parseSize(s string) int64 {
+	m := sizePattern.FindStringSubmatch(s)
+	if m == nil {
+		return -1
+	}
+	value, _ := strconv.ParseFloat(m[1], 64)
+	switch m[2] {
+	case "k":
+		value *= 1 << 10
+	case "m":
+		value *= 1 << 20
+	case "g":
+		value *= 1 << 30
+	case "t":
+		value *= 1 << 40
+	}
+	return int64(value)
+}
+
+// Reports whether the name is a full path (printed with /fp) rather than a bare name.
+func isAbs(name string) bool {
+	return strings.HasPrefix(name, `\\`) || strings.HasPrefix(name, "/") || (len(name) > 2 && name[1] == ':' && name[2] == '\\')
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseFile(t *testing.T, name string) []Event {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	events, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+// Summary lines are checked separately, only their presence matters here.
+func withoutSummaryLines(events []Event) []Event {
+	for i, event := range events {
+		if _, ok := event.(SummaryEvent); ok {
+			events[i] = SummaryEvent{}
+		}
+	}
+	return events
+}
+
+func TestParseDefault(t *testing.T) {
+	header := HeaderEvent{
+		Started:       "Monday, January 1, 2024 10:00:00 AM",
+		Source:        "C:\\source\\",
+		Destination:   "D:\\dest\\",
+		Files:         []string{"*.docx", "*.xlsx"},
+		ExcludedFiles: []string{"*.tmp"},
+		ExcludedDirs:  []string{"node_modules"},
+		Options:       "/S /E /DCOPY:DA /COPY:DAT /R:3 /W:5",
+	}
+	want := []Event{
+		header,
+		DirEvent{Files: 2, Path: "C:\\source\\"},
+		FileEvent{Class: "New File", Size: 100, Name: "a.docx", Path: "C:\\source\\a.docx"},
+		ProgressEvent{Percent: 0, Path: "C:\\source\\a.docx"},
+		ProgressEvent{Percent: 100, Path: "C:\\source\\a.docx"},
+		FileEvent{Class: "New File", Size: 1572864, Name: "big.xlsx", Path: "C:\\source\\big.xlsx"},
+		ProgressEvent{Percent: 0, Path: "C:\\source\\big.xlsx"},
+		ProgressEvent{Percent: 50, Path: "C:\\source\\big.xlsx"},
+		ProgressEvent{Percent: 100, Path: "C:\\source\\big.xlsx"},
+		FileEvent{Class: "*EXTRA File", Size: 20, Name: "old.docx", Path: "D:\\dest\\old.docx"},
+		DirEvent{Class: "New Dir", Files: 1, Path: "C:\\source\\sub\\"},
+		FileEvent{Class: "Newer", Size: 1024, Name: "b.docx", Path: "C:\\source\\sub\\b.docx"},
+		ProgressEvent{Percent: 100, Path: "C:\\source\\sub\\b.docx"},
+		DirEvent{Class: "*EXTRA Dir", Files: -1, Path: "D:\\dest\\gone\\"},
+		SummaryEvent{},
+	}
+	have := withoutSummaryLines(parseFile(t, "default.log"))
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\n,want: %+v\n", have, want)
+	}
+}
+
+func TestParseSummaryLines(t *testing.T) {
+	events := parseFile(t, "default.log")
+	summary, ok := events[len(events)-1].(SummaryEvent)
+	if !ok {
+		t.Fatalf("last event is %T, want SummaryEvent", events[len(events)-1])
+	}
+	if len(summary.Lines) != 8 || !strings.Contains(summary.Lines[0], "Total") || !strings.HasPrefix(strings.TrimSpace(summary.Lines[7]), "Ended :") {
+		t.Errorf("unexpected summary lines: %q", summary.Lines)
+	}
+}
+
+func TestParseFullPathsTimestampsBytesNoClass(t *testing.T) {
+	stamp := func(s string) time.Time {
+		tm, _ := time.ParseInLocation(timeLayout, s, time.Local)
+		return tm
+	}
+	want := []Event{
+		DirEvent{Files: 2, Path: "C:\\source\\"},
+		FileEvent{Size: 100, Time: stamp("2023/12/31 09:15:00"), Name: "C:\\source\\a.docx", Path: "C:\\source\\a.docx"},
+		FileEvent{Size: 1572864, Time: stamp("2023/12/30 18:00:05"), Name: "C:\\source\\big.xlsx", Path: "C:\\source\\big.xlsx"},
+		DirEvent{Files: 1, Path: "C:\\source\\sub\\"},
+		FileEvent{Size: 1024, Time: stamp("2023/12/29 08:00:00"), Name: "C:\\source\\sub\\b.docx", Path: "C:\\source\\sub\\b.docx"},
+	}
+	have := parseFile(t, "fp_ts_bytes_nc.log")
+	if len(have) != len(want)+2 {
+		t.Fatalf("have %d events, want %d", len(have), len(want)+2)
+	}
+	if have := have[1 : len(have)-1]; !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\n,want: %+v\n", have, want)
+	}
+}
+
+func TestParseNoSizeNoHeaderNoSummary(t *testing.T) {
+	want := []Event{
+		DirEvent{Files: 1, Path: "C:\\source\\"},
+		FileEvent{Class: "New File", Size: -1, Name: "a.docx", Path: "C:\\source\\a.docx"},
+		ProgressEvent{Percent: 100, Path: "C:\\source\\a.docx"},
+	}
+	if have := parseFile(t, "ns_njh_njs.log"); !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\n,want: %+v\n", have, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	stamp := func(s string) time.Time {
+		tm, _ := time.ParseInLocation(timeLayout, s, time.Local)
+		return tm
+	}
+	locked := "The process cannot access the file because it is being used by another process."
+	file := FileEvent{Class: "New File", Size: 100, Name: "locked.docx", Path: "C:\\source\\locked.docx"}
+	want := []Event{
+		DirEvent{Files: 1, Path: "C:\\source\\"},
+		file,
+		ErrorEvent{Time: stamp("2024/01/01 10:00:00"), Code: 32, Action: "Copying File", Path: "C:\\source\\locked.docx", Message: locked},
+		RetryEvent{Wait: 5 * time.Second},
+		file,
+		ErrorEvent{Time: stamp("2024/01/01 10:00:05"), Code: 32, Action: "Copying File", Path: "C:\\source\\locked.docx", Message: locked},
+		ErrorEvent{Message: "RETRY LIMIT EXCEEDED."},
+		ErrorEvent{Time: stamp("2024/01/01 10:00:05"), Code: 5, Action: "Accessing Destination Directory", Path: "\\\\server\\share\\", Message: "Access is denied."},
+	}
+	if have := parseFile(t, "errors.log"); !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\n,want: %+v\n", have, want)
+	}
+}
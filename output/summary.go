@@ -0,0 +1,164 @@
+package output
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoSummary is returned by ParseSummary when the output doesn't contain a job summary.
+var ErrNoSummary = errors.New("output: no job summary found")
+
+// Counts is one row of the summary table.
+type Counts struct {
+	Total    int64
+	Copied   int64
+	Skipped  int64
+	Mismatch int64
+	Failed   int64
+	Extras   int64
+}
+
+// Times is the Times row of the summary table. Robocopy leaves the Skipped and
+// Mismatch columns empty for this row.
+type Times struct {
+	Total  time.Duration
+	Copied time.Duration
+	Failed time.Duration
+	Extras time.Duration
+}
+
+// Summary is the job summary robocopy prints at the end of a run.
+type Summary struct {
+	Dirs  Counts
+	Files Counts
+	// Byte counts are approximated when robocopy printed scaled values such as 1.23 g.
+	// Use /bytes to get exact values.
+	Bytes              Counts
+	Times              Times
+	BytesPerSecond     float64 // From the Bytes/sec. speed line.
+	MegabytesPerMinute float64 // From the MegaBytes/min. speed line.
+	Ended              string  // End time exactly as printed, the format depends on the system locale.
+}
+
+var (
+	rowPattern      = regexp.MustCompile(`^\s*(Dirs|Files|Bytes|Times|Speed|Ended)\s*:\s*(.*)$`)
+	valuePattern    = regexp.MustCompile(`\d+(?:\.\d+)?(?: [kmgt]\b)?`)
+	durationPattern = regexp.MustCompile(`(\d+):(\d\d):(\d\d)`)
+	speedPattern    = regexp.MustCompile(`^([\d.,]+)\s+(Bytes/sec|MegaBytes/min)`)
+)
+
+// ParseSummary reads robocopy output, or just its summary block, and returns the job
+// summary. If the output contains several summaries (e.g. a log file appended to with
+// /log+) the last one is returned. It returns ErrNoSummary if there is none. If a speed
+// can't be parsed, the summary is returned without it along with the error.
+func ParseSummary(r io.Reader) (*Summary, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	scanner.Split(scanLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := rowPattern.FindStringSubmatch(line); m != nil && m[1] == "Dirs" {
+			lines = nil // a new summary starts
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	summary, err := parseSummaryLines(lines)
+	if summary == nil {
+		return nil, ErrNoSummary
+	}
+	return summary, err
+}
+
+// Returns nil if the lines don't contain at least the Dirs and Files rows. The error
+// reports a speed that couldn't be parsed, the rest of the summary is still returned.
+func parseSummaryLines(lines []string) (*Summary, error) {
+	var s Summary
+	var dirs, files bool
+	var err error
+	for _, line := range lines {
+		m := rowPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := strings.TrimSpace(m[2])
+		switch m[1] {
+		case "Dirs":
+			dirs = parseCounts(value, &s.Dirs)
+		case "Files":
+			files = parseCounts(value, &s.Files)
+		case "Bytes":
+			parseCounts(value, &s.Bytes)
+		case "Times":
+			var times []time.Duration
+			for _, d := range durationPattern.FindAllStringSubmatch(value, -1) {
+				hours, _ := strconv.Atoi(d[1])
+				minutes, _ := strconv.Atoi(d[2])
+				seconds, _ := strconv.Atoi(d[3])
+				times = append(times, time.Duration(hours)*time.Hour+time.Duration(minutes)*time.Minute+time.Duration(seconds)*time.Second)
+			}
+			if len(times) == 4 {
+				s.Times = Times{Total: times[0], Copied: times[1], Failed: times[2], Extras: times[3]}
+			}
+		case "Speed":
+			if sm := speedPattern.FindStringSubmatch(value); sm != nil {
+				speed, perr := parseSpeed(sm[1], sm[2] == "MegaBytes/min")
+				switch {
+				case perr != nil:
+					err = fmt.Errorf("output: invalid speed %q: %w", value, perr)
+				case sm[2] == "Bytes/sec":
+					s.BytesPerSecond = speed
+				default:
+					s.MegabytesPerMinute = speed
+				}
+			}
+		case "Ended":
+			s.Ended = value
+		}
+	}
+	if !dirs || !files {
+		return nil, nil
+	}
+	return &s, err
+}
+
+// Parses a speed printed with the digit grouping of the system locale, such as
+// 109,190,862 Bytes/sec. or 6.247,952 MegaBytes/min. The bytes per second are whole
+// numbers, while the megabytes per minute have decimals after the last . or , mark.
+func parseSpeed(number string, decimals bool) (float64, error) {
+	whole, fraction := number, ""
+	if i := strings.LastIndexAny(number, ".,"); decimals && i >= 0 {
+		whole, fraction = number[:i], number[i+1:]
+	}
+	digits := strings.NewReplacer(".", "", ",", "").Replace(whole)
+	if fraction != "" {
+		digits += "." + fraction
+	}
+	return strconv.ParseFloat(digits, 64)
+}
+
+// Fills the counts from a row of six values, returning false if the row is malformed.
+func parseCounts(row string, c *Counts) bool {
+	values := valuePattern.FindAllString(row, -1)
+	if len(values) != 6 {
+		return false
+	}
+	*c = Counts{
+		Total:    parseSize(values[0]),
+		Copied:   parseSize(values[1]),
+		Skipped:  parseSize(values[2]),
+		Mismatch: parseSize(values[3]),
+		Failed:   parseSize(values[4]),
+		Extras:   parseSize(values[5]),
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSummary(t *testing.T) {
+	f, err := os.Open("testdata/default.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	have, err := ParseSummary(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Summary{
+		Dirs:               Counts{Total: 2, Copied: 1, Skipped: 1, Extras: 1},
+		Files:              Counts{Total: 3, Copied: 3, Extras: 1},
+		Bytes:              Counts{Total: 1572864, Copied: 1572864, Extras: 20},
+		Times:              Times{Total: time.Second, Copied: time.Second},
+		BytesPerSecond:     1638400,
+		MegabytesPerMinute: 93.75,
+		Ended:              "Monday, January 1, 2024 10:00:01 AM",
+	}
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\n,want: %+v\n", have, want)
+	}
+}
+
+func TestParseSummaryGroupedSpeed(t *testing.T) {
+	f, err := os.Open("testdata/grouped.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	have, err := ParseSummary(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have.BytesPerSecond != 109190862 || have.MegabytesPerMinute != 6247.952 || have.Bytes.Copied != 12338767406 {
+		t.Errorf("unexpected summary: %+v", have)
+	}
+
+	// Locales with a decimal comma group the digits with dots.
+	text := `
+    Dirs :         1         1         0         0         0         0
+   Files :         1         1         0         0         0         0
+   Speed :         109.190.862 Bytes/sec.
+   Speed :           6.247,952 MegaBytes/min.
+`
+	if have, err = ParseSummary(strings.NewReader(text)); err != nil || have.BytesPerSecond != 109190862 || have.MegabytesPerMinute != 6247.952 {
+		t.Errorf("have: %+v, %v", have, err)
+	}
+
+	text = strings.Replace(text, "6.247,952", ",", 1)
+	have, err = ParseSummary(strings.NewReader(text))
+	if err == nil || have == nil || have.BytesPerSecond != 109190862 || have.MegabytesPerMinute != 0 {
+		t.Errorf("have: %+v, %v", have, err)
+	}
+	events, err := Parse(strings.NewReader(strings.Repeat("-", 78) + text))
+	if err != nil || len(events) != 1 || events[0].(SummaryEvent).Err == nil {
+		t.Errorf("have: %+v, %v", events, err)
+	}
+}
+
+func TestParseSummaryBytesAndScaled(t *testing.T) {
+	text := `
+               Total    Copied   Skipped  Mismatch    FAILED    Extras
+    Dirs :       120       100        20         0         0         0
+   Files :     52310     50000      2300         0        10         0
+   Bytes :  1.234 g   1.000 g  239.62 m         0    12.5 k         0
+   Times :   1:02:03   0:59:00                       0:00:10   0:00:00
+`
+	have, err := ParseSummary(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Counts{Total: 1324997410, Copied: 1 << 30, Skipped: 251259781, Failed: 12800}
+	if have.Bytes != want {
+		t.Errorf("have: %+v\n,want: %+v\n", have.Bytes, want)
+	}
+	if have.Files.Failed != 10 || have.Times.Total != time.Hour+2*time.Minute+3*time.Second || have.Times.Failed != 10*time.Second {
+		t.Errorf("unexpected summary: %+v", have)
+	}
+
+	text = strings.Replace(text, "1.234 g   1.000 g  239.62 m         0    12.5 k", "1324997410 1073741824 251259781 0 12800", 1)
+	if have, err = ParseSummary(strings.NewReader(text)); err != nil || have.Bytes != want {
+		t.Errorf("have: %+v, %v\n,want: %+v\n", have, err, want)
+	}
+}
+
+func TestParseSummaryMissing(t *testing.T) {
+	f, err := os.Open("testdata/ns_njh_njs.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := ParseSummary(f); !errors.Is(err, ErrNoSummary) {
+		t.Errorf("have: %v, want: %v", err, ErrNoSummary)
+	}
+}
@@ -0,0 +1,505 @@
+package gorobocopy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/flags/aflags"
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+	"github.com/aggellos2001/go-robocopy/flags/dcopyflags"
+)
+
+var (
+	// The switch is not one that robocopy (or this package) knows about.
+	ErrUnknownSwitch = errors.New("unknown switch")
+	// The switch requires a value (e.g. /lev:n) but none was given.
+	ErrMissingValue = errors.New("missing value")
+	// The switch takes no value but one was given.
+	ErrUnexpectedValue = errors.New("unexpected value")
+	// The argument is not a switch and follows the switches, where only /xf and /xd take names.
+	ErrUnexpectedArgument = errors.New("unexpected argument")
+	// The value of the switch could not be interpreted.
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// ParseError is returned when a command line token can't be parsed.
+// Use errors.Is on it to check the reason against the Err* variables of this package.
+type ParseError struct {
+	Token string // The offending token exactly as it was given.
+	Err   error  // The reason the token was rejected.
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("gorobocopy: %v: %q", e.Err, e.Token)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseCommandLine builds a Robocopy instance from the given arguments. It is the inverse
+// of GetCommandArgs, so the arguments must not include the robocopy executable itself.
+// Switches are matched case-insensitively. Option structs are only set for the option
+// groups that appear in the arguments. POSIX paths are accepted for the directories and
+// after /xf and /xd, a single-element one such as /data as long as it isn't the name of a
+// switch.
+func ParseCommandLine(args []string) (*Robocopy, error) {
+	r := &Robocopy{}
+	positional := 0    // the number of positional arguments, -1 once a switch appears
+	var list *[]string // set while consuming the names following /xf or /xd
+	for _, arg := range args {
+		// Where a path is expected, names unknown as switches are single-element POSIX
+		// paths such as /data.
+		path := list != nil || positional == 0 || positional == 1
+		if !isSwitch(arg) || path && !knownSwitch(arg) {
+			switch {
+			case list != nil:
+				*list = append(*list, arg)
+			case positional < 0:
+				return nil, &ParseError{Token: arg, Err: ErrUnexpectedArgument}
+			case positional == 0:
+				r.source = arg
+			case positional == 1:
+				r.destination = arg
+			case arg != "":
+				// Every argument after the directories is a file specification.
+				r.files = append(r.files, arg)
+			}
+			if positional >= 0 {
+				positional++
+			}
+			continue
+		}
+		// Once a switch appears no more positional arguments are accepted.
+		positional = -1
+		list = nil
+		var err error
+		list, err = r.parseSwitch(arg)
+		if err != nil {
+			return nil, &ParseError{Token: arg, Err: err}
+		}
+	}
+	return r, nil
+}
+
+// Reports whether the argument is a switch. Arguments starting with a slash are switches
+// unless their name contains another one, so POSIX paths such as /home/user work too.
+func isSwitch(arg string) bool {
+	name, _, _ := strings.Cut(arg, ":")
+	return strings.HasPrefix(arg, "/") && !strings.Contains(name[1:], "/")
+}
+
+// Reports whether the name of the switch is one robocopy knows, whatever its value.
+func knownSwitch(arg string) bool {
+	_, err := (&Robocopy{}).parseSwitch(arg)
+	return !errors.Is(err, ErrUnknownSwitch)
+}
+
+// ParseCommandString splits the command line using the Windows argument quoting rules
+// and parses the result with ParseCommandLine. A leading robocopy or robocopy.exe
+// program name is skipped, so lines taken from batch files can be used as-is.
+func ParseCommandString(cmdline string) (*Robocopy, error) {
+	args := splitCommandLine(cmdline)
+	if len(args) > 0 {
+		name := strings.ToLower(args[0][strings.LastIndexAny(args[0], `\/`)+1:])
+		if name == "robocopy" || name == "robocopy.exe" {
+			args = args[1:]
+		}
+	}
+	return ParseCommandLine(args)
+}
+
+// Splits a command line the same way CommandLineToArgvW does. Backslashes are literal
+// unless they precede a double quote, in which case each pair becomes one backslash and
+// an odd one out escapes the quote.
+func splitCommandLine(s string) (args []string) {
+	var current strings.Builder
+	inArg, inQuotes := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			n := 0
+			for i < len(s) && s[i] == '\\' {
+				n++
+				i++
+			}
+			if i < len(s) && s[i] == '"' {
+				current.WriteString(strings.Repeat(`\`, n/2))
+				if n%2 == 1 {
+					current.WriteByte('"')
+				} else {
+					i-- // let the quote be handled as a delimiter
+				}
+			} else {
+				current.WriteString(strings.Repeat(`\`, n))
+				i--
+			}
+			inArg = true
+		case c == '"':
+			if inQuotes && i+1 < len(s) && s[i+1] == '"' {
+				current.WriteByte('"')
+				i++
+			} else {
+				inQuotes = !inQuotes
+			}
+			inArg = true
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// Applies a single switch to the instance. If the switch starts a list of names
+// (/xf and /xd) it returns the slice that following arguments should be appended to.
+func (r *Robocopy) parseSwitch(arg string) (list *[]string, err error) {
+	name, value, hasValue := strings.Cut(arg[1:], ":")
+	name = strings.ToLower(name)
+
+	copyOpt := func() *CopyOptions {
+		if r.copyOpt == nil {
+			r.copyOpt = &CopyOptions{}
+		}
+		return r.copyOpt
+	}
+	throttlingOpt := func() *CopyFileThrottlingOptions {
+		if r.throttlingOpt == nil {
+			r.throttlingOpt = &CopyFileThrottlingOptions{}
+		}
+		return r.throttlingOpt
+	}
+	fileslOpt := func() *FileSelectionOptions {
+		if r.fileslOpt == nil {
+			r.fileslOpt = &FileSelectionOptions{}
+		}
+		return r.fileslOpt
+	}
+	retryOpt := func() *RetryOptions {
+		if r.retryOpt == nil {
+			r.retryOpt = &RetryOptions{}
+		}
+		return r.retryOpt
+	}
+	loggingOpt := func() *LoggingOptions {
+		if r.loggingOpt == nil {
+			r.loggingOpt = &LoggingOptions{}
+		}
+		return r.loggingOpt
+	}
+	jobOpt := func() *JobOptions {
+		if r.jobOpt == nil {
+			r.jobOpt = &JobOptions{}
+		}
+		return r.jobOpt
+	}
+
+	// Switches that take no value.
+	var boolean *bool
+	switch name {
+	case "s":
+		boolean = &copyOpt().S
+	case "e":
+		boolean = &copyOpt().E
+	case "z":
+		boolean = &copyOpt().Z
+	case "b":
+		boolean = &copyOpt().B
+	case "zb":
+		boolean = &copyOpt().Zb
+	case "j":
+		boolean = &copyOpt().J
+	case "efsraw":
+		boolean = &copyOpt().EsfRaw
+	case "sec":
+		boolean = &copyOpt().Sec
+	case "copyall":
+		boolean = &copyOpt().CopyAll
+	case "nocopy":
+		boolean = &copyOpt().NoCopy
+	case "secfix":
+		boolean = &copyOpt().SecFix
+	case "timfix":
+		boolean = &copyOpt().TimFix
+	case "purge":
+		boolean = &copyOpt().Purge
+	case "mir":
+		boolean = &copyOpt().Mir
+	case "mov":
+		boolean = &copyOpt().Mov
+	case "move":
+		boolean = &copyOpt().Move
+	case "create":
+		boolean = &copyOpt().Create
+	case "fat":
+		boolean = &copyOpt().Fat
+	case "256":
+		boolean = &copyOpt().NoMoreThan256
+	case "pf":
+		boolean = &copyOpt().Pf
+	case "sj":
+		boolean = &copyOpt().Sj
+	case "sl":
+		boolean = &copyOpt().Sl
+	case "nodcopy":
+		boolean = &copyOpt().Nodcopy
+	case "nooffload":
+		boolean = &copyOpt().Nooffload
+	case "compress":
+		boolean = &copyOpt().Compress
+	case "sparse":
+		boolean = &copyOpt().Sparse
+	case "a":
+		boolean = &fileslOpt().A
+	case "m":
+		boolean = &fileslOpt().M
+	case "xc":
+		boolean = &fileslOpt().Xc
+	case "xn":
+		boolean = &fileslOpt().Xn
+	case "xo":
+		boolean = &fileslOpt().Xo
+	case "xx":
+		boolean = &fileslOpt().Xx
+	case "xl":
+		boolean = &fileslOpt().Xl
+	case "im":
+		boolean = &fileslOpt().Im
+	case "is":
+		boolean = &fileslOpt().Is
+	case "it":
+		boolean = &fileslOpt().It
+	case "xj":
+		boolean = &fileslOpt().Xj
+	case "fft":
+		boolean = &fileslOpt().Fft
+	case "dst":
+		boolean = &fileslOpt().Dst
+	case "xjd":
+		boolean = &fileslOpt().Xjd
+	case "xjf":
+		boolean = &fileslOpt().Xjf
+	case "reg":
+		boolean = &retryOpt().Reg
+	case "tbd":
+		boolean = &retryOpt().Tbd
+	case "l":
+		boolean = &loggingOpt().L
+	case "x":
+		boolean = &loggingOpt().X
+	case "v":
+		boolean = &loggingOpt().V
+	case "ts":
+		boolean = &loggingOpt().Ts
+	case "fp":
+		boolean = &loggingOpt().Fp
+	case "bytes":
+		boolean = &loggingOpt().Bytes
+	case "ns":
+		boolean = &loggingOpt().Ns
+	case "nc":
+		boolean = &loggingOpt().Nc
+	case "nfl":
+		boolean = &loggingOpt().Nfl
+	case "ndl":
+		boolean = &loggingOpt().Ndl
+	case "np":
+		boolean = &loggingOpt().Np
+	case "eta":
+		boolean = &loggingOpt().Eta
+	case "tee":
+		boolean = &loggingOpt().Tee
+	case "njh":
+		boolean = &loggingOpt().Njh
+	case "njs":
+		boolean = &loggingOpt().Njs
+	case "unicode":
+		boolean = &loggingOpt().Unicode
+	case "quit":
+		boolean = &jobOpt().Quit
+	case "nosd":
+		boolean = &jobOpt().Nosd
+	case "nodd":
+		boolean = &jobOpt().Nodd
+	case "if":
+		boolean = &jobOpt().If
+	case "xf":
+		list = &fileslOpt().Xf
+	case "xd":
+		list = &fileslOpt().Xd
+	}
+	if boolean != nil || list != nil {
+		if hasValue {
+			return nil, ErrUnexpectedValue
+		}
+		if boolean != nil {
+			*boolean = true
+		}
+		return list, nil
+	}
+
+	// Switches whose value is optional.
+	if !hasValue {
+		switch name {
+		case "mt":
+			copyOpt().Mt = 8 // robocopy's default thread count
+			return nil, nil
+		case "lfsm":
+			retryOpt().Lfsm = true
+			return nil, nil
+		}
+	}
+
+	// Switches that require a value.
+	var integer *int
+	var size *ByteSize
+	var age *AgeFilter
+	var seconds *time.Duration
+	var window *RunWindow
+	var text *string
+	switch name {
+	case "lev":
+		integer = &copyOpt().Lev
+	case "mon":
+		integer = &copyOpt().Mon
+	case "mot":
+		integer = &copyOpt().Mot
+	case "ipg":
+		integer = &copyOpt().Ipg
+	case "mt":
+		integer = &copyOpt().Mt
+	case "max":
+		size = &fileslOpt().Max
+	case "min":
+		size = &fileslOpt().Min
+	case "maxage":
+		age = &fileslOpt().Maxage
+	case "minage":
+		age = &fileslOpt().Minage
+	case "maxlad":
+		age = &fileslOpt().Maxlad
+	case "minlad":
+		age = &fileslOpt().Minlad
+	case "r":
+		integer = &retryOpt().R
+	case "w":
+		seconds = &retryOpt().W
+	case "iomaxsize":
+		size = &throttlingOpt().Iomaxsize
+	case "iorate":
+		size = &throttlingOpt().Iorate
+	case "threshold":
+		size = &throttlingOpt().Threshold
+	case "lfsm":
+		size = &retryOpt().LfsmSize
+	case "rh":
+		window = &copyOpt().Rh
+	case "log":
+		text = &loggingOpt().Log
+	case "log+":
+		text = &loggingOpt().LogPlus
+	case "unilog":
+		text = &loggingOpt().UniLog
+	case "unilog+":
+		text = &loggingOpt().UniLogPlus
+	case "job":
+		text = &jobOpt().Job
+	case "save":
+		text = &jobOpt().Save
+	case "copy":
+		flag, err := parseFlags(value, copyflags.Parse)
+		if err != nil {
+			return nil, err
+		}
+		copyOpt().Copy = flag
+		return nil, nil
+	case "dcopy":
+		flag, err := parseFlags(value, dcopyflags.Parse)
+		if err != nil {
+			return nil, err
+		}
+		copyOpt().Dcopy = flag
+		return nil, nil
+	case "a+", "a-", "ia", "xa":
+		flag, err := parseFlags(value, aflags.Parse)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "a+":
+			copyOpt().APlus = flag
+		case "a-":
+			copyOpt().AMinus = flag
+		case "ia":
+			fileslOpt().Ia = flag
+		case "xa":
+			fileslOpt().Xa = flag
+		}
+		return nil, nil
+	default:
+		return nil, ErrUnknownSwitch
+	}
+	if !hasValue || value == "" {
+		return nil, ErrMissingValue
+	}
+	switch {
+	case integer != nil:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+		*integer = n
+	case size != nil:
+		n, err := ParseByteSize(value)
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+		*size = n
+	case age != nil:
+		filter, err := ParseAgeFilter(value)
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+		*age = filter
+	case seconds != nil:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+		*seconds = time.Duration(n) * time.Second
+	case window != nil:
+		w, err := ParseRunWindow(value)
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+		*window = w
+	case text != nil:
+		*text = value
+	}
+	return nil, nil
+}
+
+// Parses the letters of a flag switch with the Parse function of its flag package.
+func parseFlags[T ~uint16](value string, parse func(string) (T, error)) (T, error) {
+	if value == "" {
+		return 0, ErrMissingValue
+	}
+	flag, err := parse(value)
+	if err != nil {
+		return 0, ErrInvalidValue
+	}
+	return flag, nil
+}
@@ -0,0 +1,261 @@
+package gorobocopy
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/flags/aflags"
+	"github.com/aggellos2001/go-robocopy/flags/copyflags"
+	"github.com/aggellos2001/go-robocopy/flags/dcopyflags"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	have, err := ParseCommandLine([]string{
+		"C:\\source", "D:\\destination", "*.*",
+		"/E", "/copy:dat", "/mt:4", "/xf", "*.tmp", "*.bak", "/xd", "bin",
+		"/a+:RH", "/iorate:10m", "/lfsm", "/log:C:\\logs\\run.log", "/save:MyJob",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewRobocopy("C:\\source", "D:\\destination", "*.*")
+	want.SetCopyOptions(&CopyOptions{
+		E:     true,
+		Mt:    4,
+		Copy:  copyflags.D | copyflags.A | copyflags.T,
+		APlus: aflags.R | aflags.H,
+	})
+	want.SetThrottlingOptions(&CopyFileThrottlingOptions{
+		Iorate: 10 * Megabyte,
+	})
+	want.SetFileSelectionOptions(&FileSelectionOptions{
+		Xf: []string{"*.tmp", "*.bak"},
+		Xd: []string{"bin"},
+	})
+	want.SetRetryOptions(&RetryOptions{Lfsm: true})
+	want.SetLoggingOptions(&LoggingOptions{Log: "C:\\logs\\run.log"})
+	want.SetJobOptions(&JobOptions{Save: "MyJob"})
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\n,want: %+v\n", have, want)
+	}
+}
+
+func TestParseCommandLinePosixPaths(t *testing.T) {
+	have, err := ParseCommandLine([]string{"/home/me/src", "/mnt/backup", "/xd", "/home/me/src/tmp", "cache", "/e", "/log:/var/log/sync.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewRobocopy("/home/me/src", "/mnt/backup", "")
+	want.SetCopyOptions(&CopyOptions{E: true})
+	want.SetFileSelectionOptions(&FileSelectionOptions{Xd: []string{"/home/me/src/tmp", "cache"}})
+	want.SetLoggingOptions(&LoggingOptions{Log: "/var/log/sync.log"})
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("have: %+v\n,want: %+v\n", have, want)
+	}
+}
+
+func TestParseCommandLineRootPaths(t *testing.T) {
+	tests := []struct {
+		args []string
+		want func() *Robocopy
+	}{
+		{[]string{"/data", "/backup", "/e"}, func() *Robocopy {
+			r := NewRobocopy("/data", "/backup")
+			r.SetCopyOptions(&CopyOptions{E: true})
+			return r
+		}},
+		{[]string{"/data", "/backup", "/xd", "/tmp", "/e"}, func() *Robocopy {
+			r := NewRobocopy("/data", "/backup")
+			r.SetCopyOptions(&CopyOptions{E: true})
+			r.SetFileSelectionOptions(&FileSelectionOptions{Xd: []string{"/tmp"}})
+			return r
+		}},
+	}
+	for _, test := range tests {
+		have, err := ParseCommandLine(test.args)
+		if err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+		if want := test.want(); !reflect.DeepEqual(want, have) {
+			t.Errorf("%q: have: %+v\n,want: %+v\n", test.args, have, want)
+		}
+	}
+}
+
+func TestParseCommandLineFiles(t *testing.T) {
+	tests := []struct {
+		args  []string
+		files []string
+	}{
+		{[]string{"src", "dst"}, nil},
+		{[]string{"src", "dst", "/e"}, nil},
+		{[]string{"src", "dst", "", "/e"}, nil},
+		{[]string{"src", "dst", "*.docx", "*.xlsx", "report?.pdf", "/e", "/xf", "*.tmp"}, []string{"*.docx", "*.xlsx", "report?.pdf"}},
+	}
+	for _, test := range tests {
+		have, err := ParseCommandLine(test.args)
+		if err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+		if !slices.Equal(have.GetFiles(), test.files) {
+			t.Errorf("%q: have: %q, want: %q", test.args, have.GetFiles(), test.files)
+			continue
+		}
+		if args := have.GetCommandArgs(); !slices.Equal(args[:2+len(test.files)], append([]string{"src", "dst"}, test.files...)) {
+			t.Errorf("%q: written as %q", test.args, args)
+		}
+	}
+}
+
+func TestParseCommandLineErrors(t *testing.T) {
+	tests := []struct {
+		args  []string
+		token string
+		err   error
+	}{
+		{[]string{"src", "dst", "*.*", "/bogus"}, "/bogus", ErrUnknownSwitch},
+		{[]string{"src", "dst", "*.*", "/lev"}, "/lev", ErrMissingValue},
+		{[]string{"src", "dst", "*.*", "/mir:1"}, "/mir:1", ErrUnexpectedValue},
+		{[]string{"src", "dst", "*.*", "/mt:many"}, "/mt:many", ErrInvalidValue},
+		{[]string{"src", "dst", "*.*", "/copy:DAQ"}, "/copy:DAQ", ErrInvalidValue},
+		{[]string{"src", "dst", "*.*", "/iorate:10t"}, "/iorate:10t", ErrInvalidValue},
+		{[]string{"src", "dst", "*.*", "/e", "extra"}, "extra", ErrUnexpectedArgument},
+		{[]string{"src", "dst", "/e", "*.*"}, "*.*", ErrUnexpectedArgument},
+	}
+	for _, test := range tests {
+		_, err := ParseCommandLine(test.args)
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Token != test.token || !errors.Is(err, test.err) {
+			t.Errorf("%v: have: %v, want: %v on %q", test.args, err, test.err, test.token)
+		}
+	}
+}
+
+func TestParseCommandString(t *testing.T) {
+	r, err := ParseCommandString(`robocopy.exe "C:\Program Files\App" \\server\share\app *.* /mir /log:"C:\my logs\app.log"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`C:\Program Files\App`, `\\server\share\app`, "*.*", "/mir", `/log:C:\my logs\app.log`}
+	if have := r.GetCommandArgs(); !slices.Equal(want, have) {
+		t.Errorf("have: %q\n,want: %q\n", have, want)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		want    []string
+	}{
+		{`a b  c`, []string{"a", "b", "c"}},
+		{`"a b" c`, []string{"a b", "c"}},
+		{`"C:\dir\\" x`, []string{`C:\dir\`, "x"}},
+		{`"C:\dir\" x`, []string{`C:\dir" x`}},
+		{`a\\b \"c`, []string{`a\\b`, `"c`}},
+		{`"say ""hi"""`, []string{`say "hi"`}},
+		{`"" x`, []string{"", "x"}},
+	}
+	for _, test := range tests {
+		if have := splitCommandLine(test.cmdline); !slices.Equal(test.want, have) {
+			t.Errorf("%s: have: %q, want: %q", test.cmdline, have, test.want)
+		}
+	}
+}
+
+// Parsing the arguments of a randomly configured instance must give back the same instance.
+func TestParseRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 2000; i++ {
+		want := NewRobocopy("src"+strconv.Itoa(i), "dst", randomName(rnd))
+		randomOptions(rnd, reflect.ValueOf(want).Elem())
+		args := want.GetCommandArgs()
+		have, err := ParseCommandLine(args)
+		if err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		if !reflect.DeepEqual(want, have) {
+			t.Fatalf("%q:\nhave: %+v\nwant: %+v", args, have, want)
+		}
+	}
+}
+
+// Sets every option struct pointer of the Robocopy value to either nil or a random option set.
+func randomOptions(rnd *rand.Rand, r reflect.Value) {
+	for i := 0; i < r.NumField(); i++ {
+		field := r.Field(i)
+		if field.Kind() != reflect.Pointer || !strings.HasSuffix(field.Type().Elem().Name(), "Options") || rnd.IntN(4) == 0 {
+			continue
+		}
+		opts := reflect.New(field.Type().Elem())
+		randomFields(rnd, opts.Elem())
+		if !opts.Elem().IsZero() {
+			reflect.NewAt(field.Type(), field.Addr().UnsafePointer()).Elem().Set(opts)
+		}
+	}
+}
+
+func randomFields(rnd *rand.Rand, opts reflect.Value) {
+	for i := 0; i < opts.NumField(); i++ {
+		if rnd.IntN(3) != 0 {
+			continue
+		}
+		field := opts.Field(i)
+		switch v := field.Addr().Interface().(type) {
+		case *bool:
+			*v = true
+		case *int:
+			*v = 1 + rnd.IntN(200)
+		case *string:
+			*v = randomName(rnd)
+		case *[]string:
+			for n := 1 + rnd.IntN(3); n > 0; n-- {
+				*v = append(*v, randomName(rnd))
+			}
+		case *ByteSize:
+			*v = ByteSize(1 + rnd.Int64N(1<<40))
+			if rnd.IntN(2) == 0 {
+				*v = ByteSize(1+rnd.IntN(1000)) << (10 * rnd.IntN(4))
+			}
+		case *AgeFilter:
+			*v = AgeOf(time.Duration(1+rnd.IntN(1899)) * 24 * time.Hour)
+			if rnd.IntN(2) == 0 {
+				*v = DateOf(time.Date(1900+rnd.IntN(200), time.Month(1+rnd.IntN(12)), 1+rnd.IntN(28), 0, 0, 0, 0, time.UTC))
+			}
+		case *time.Duration:
+			*v = time.Duration(1+rnd.IntN(600)) * time.Second
+		case *RunWindow:
+			*v = RunWindow{Start: time.Duration(rnd.IntN(24*60)) * time.Minute, End: time.Duration(rnd.IntN(24*60)) * time.Minute}
+			if v.Start == v.End {
+				v.End = (v.Start + time.Hour) % (24 * time.Hour)
+			}
+		case *aflags.AFlags:
+			*v = randomFlags(rnd, aflags.Settable|aflags.O)
+		case *copyflags.CopyFlags:
+			*v = randomFlags(rnd, copyflags.D|copyflags.A|copyflags.T|copyflags.X|copyflags.S|copyflags.O|copyflags.U)
+		case *dcopyflags.DCopyFlags:
+			*v = randomFlags(rnd, dcopyflags.D|dcopyflags.A|dcopyflags.T|dcopyflags.E|dcopyflags.X)
+		default:
+			panic("no random values for " + field.Type().String())
+		}
+	}
+}
+
+// Returns a random non-empty combination of the flags in mask.
+func randomFlags[F ~uint16](rnd *rand.Rand, mask F) F {
+	for {
+		if f := F(rnd.Uint32()) & mask; f != 0 {
+			return f
+		}
+	}
+}
+
+func randomName(rnd *rand.Rand) string {
+	names := []string{"*.*", "*.tmp", "report?.pdf", "C:\\Program Files", "\\\\server\\share\\dir", "a:b", "node_modules"}
+	return names[rnd.IntN(len(names))]
+}
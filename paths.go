@@ -0,0 +1,152 @@
+package gorobocopy
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// PathKind is the form of a Windows path, which decides what it is relative to.
+type PathKind string
+
+const (
+	PathRelative      PathKind = "relative"       // Relative to the current directory, such as data\logs.
+	PathDriveRelative PathKind = "drive-relative" // Relative to the current directory of a drive, such as C:data.
+	PathRooted        PathKind = "rooted"         // Relative to the root of the current drive, such as \data.
+	PathLocal         PathKind = "local"          // A full path on a drive, such as C:\data.
+	PathUNC           PathKind = "unc"            // A path on a network share, such as \\server\share\data.
+	PathExtended      PathKind = "extended"       // An extended-length path, such as \\?\C:\data or \\?\UNC\server\share.
+)
+
+// The length from which Windows needs long path support, the length of the paths
+// /256 limits robocopy to.
+const maxShortPath = 256
+
+// PathKindOf returns the form of the path. Slashes are taken for backslashes.
+func PathKindOf(path string) PathKind {
+	path = strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`):
+		return PathExtended
+	case strings.HasPrefix(path, `\\`):
+		return PathUNC
+	case strings.HasPrefix(path, `\`):
+		return PathRooted
+	case hasDrive(path) && strings.HasPrefix(path[2:], `\`):
+		return PathLocal
+	case hasDrive(path):
+		return PathDriveRelative
+	default:
+		return PathRelative
+	}
+}
+
+// Reports whether the path starts with a drive letter and a colon.
+func hasDrive(path string) bool {
+	return len(path) >= 2 && path[1] == ':' && ('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z')
+}
+
+// NormalizePath returns the path in the form robocopy expects on Windows. Slashes become
+// backslashes, repeated separators and . elements are dropped, .. elements remove the
+// element before them without going past the root, drive letters are written in upper
+// case and trailing backslashes are stripped, as they break the quoting of the command
+// line. The roots of drives keep theirs, C: alone being the current directory of the
+// drive. Drive-relative paths such as C:data can't be resolved without that directory
+// and stay relative, like relative paths keep their leading .. elements.
+func NormalizePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	path = strings.ReplaceAll(path, "/", `\`)
+	kind := PathKindOf(path)
+	root, rest := splitRoot(path, kind)
+	var elems []string
+	for _, elem := range strings.Split(rest, `\`) {
+		switch {
+		case elem == "" || elem == ".":
+		case elem != "..":
+			elems = append(elems, elem)
+		case len(elems) > 0 && elems[len(elems)-1] != "..":
+			elems = elems[:len(elems)-1]
+		case kind == PathRelative || kind == PathDriveRelative:
+			elems = append(elems, elem)
+		}
+	}
+	switch {
+	case len(elems) > 0:
+		return root + strings.Join(elems, `\`)
+	case kind == PathUNC || kind == PathExtended && strings.EqualFold(root[4:min(len(root), 8)], `UNC\`):
+		// The root of a share needs no trailing backslash, \\?\UNC\server\share included.
+		return strings.TrimSuffix(root, `\`)
+	case root == "":
+		return "."
+	}
+	return root
+}
+
+// Splits the path into its root, with a trailing backslash unless it is relative to a
+// current directory, and the rest. The root of a share holds the server and the share.
+func splitRoot(path string, kind PathKind) (root, rest string) {
+	var prefix string
+	var n int // the number of elements after the prefix that belong to the root
+	switch kind {
+	case PathExtended:
+		prefix, path = path[:4], path[4:]
+		n = 1
+		if len(path) >= 4 && strings.EqualFold(path[:4], `UNC\`) {
+			prefix, path = prefix+`UNC\`, path[4:]
+			n = 2
+		}
+	case PathUNC:
+		prefix, path = `\\`, path[2:]
+		n = 2
+	case PathRooted:
+		return `\`, path
+	case PathLocal, PathDriveRelative:
+		root = strings.ToUpper(path[:1]) + ":"
+		if kind == PathLocal {
+			root += `\`
+		}
+		return root, path[2:]
+	default:
+		return "", path
+	}
+	elems := strings.SplitN(strings.TrimLeft(path, `\`), `\`, n+1)
+	for i := 0; i < n && i < len(elems); i++ {
+		if hasDrive(elems[i]) && len(elems[i]) == 2 {
+			elems[i] = strings.ToUpper(elems[i])
+		}
+		prefix += elems[i] + `\`
+	}
+	if len(elems) > n {
+		rest = elems[n]
+	}
+	return prefix, rest
+}
+
+// NormalizePaths normalizes the source and destination directories and the /xd and /xf
+// entries with NormalizePath. The file selection options are changed in place. Use it
+// for commands run by robocopy only, as the paths are made Windows paths.
+func (r *Robocopy) NormalizePaths() {
+	r.source, r.destination = NormalizePath(r.source), NormalizePath(r.destination)
+	if fso := r.fileslOpt; fso != nil {
+		fso.Xd, fso.Xf = normalizePaths(fso.Xd), normalizePaths(fso.Xf)
+	}
+}
+
+// Returns the paths normalized with NormalizePath, in a new slice.
+func normalizePaths(paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+	normalized := make([]string, len(paths))
+	for i, path := range paths {
+		normalized[i] = NormalizePath(path)
+	}
+	return normalized
+}
+
+// Reports whether the path needs long path support, that is when it is an extended-length
+// path or longer than 256 UTF-16 characters.
+func needsLongPaths(path string) bool {
+	return PathKindOf(path) == PathExtended || len(utf16.Encode([]rune(path))) > maxShortPath
+}
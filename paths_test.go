@@ -0,0 +1,104 @@
+package gorobocopy
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path, want string
+		kind       PathKind
+	}{
+		// Local paths.
+		{`C:\data`, `C:\data`, PathLocal},
+		{`c:/data/logs/`, `C:\data\logs`, PathLocal},
+		{`C:\data\\logs\.\run\..\old\`, `C:\data\logs\old`, PathLocal},
+		{`C:\..\..\data`, `C:\data`, PathLocal},
+		{`C:\`, `C:\`, PathLocal},
+		{`C:/`, `C:\`, PathLocal},
+		{`C:\data\..`, `C:\`, PathLocal},
+		// Network shares.
+		{`\\fileserver\share\projects\`, `\\fileserver\share\projects`, PathUNC},
+		{`//fileserver/share/projects/2024`, `\\fileserver\share\projects\2024`, PathUNC},
+		{`\\fileserver\share\`, `\\fileserver\share`, PathUNC},
+		{`\\fileserver\share\a\..\..\b`, `\\fileserver\share\b`, PathUNC},
+		{`\\fileserver\share\..`, `\\fileserver\share`, PathUNC},
+		// Extended-length paths.
+		{`\\?\C:\data\`, `\\?\C:\data`, PathExtended},
+		{`\\?\c:\`, `\\?\C:\`, PathExtended},
+		{`\\?\UNC\fileserver\share\x\..\y\`, `\\?\UNC\fileserver\share\y`, PathExtended},
+		{`\\?\UNC\fileserver\share\`, `\\?\UNC\fileserver\share`, PathExtended},
+		{`//?/C:/data`, `\\?\C:\data`, PathExtended},
+		{`\\?\Volume{0b1a2c3d-0000-0000-0000-100000000000}\data\`, `\\?\Volume{0b1a2c3d-0000-0000-0000-100000000000}\data`, PathExtended},
+		{`\\.\D:\backup`, `\\.\D:\backup`, PathExtended},
+		// Paths relative to a current directory.
+		{`data\logs\`, `data\logs`, PathRelative},
+		{`..\data\..\..\logs`, `..\..\logs`, PathRelative},
+		{`.\`, `.`, PathRelative},
+		{`c:data\..\..\logs`, `C:..\logs`, PathDriveRelative},
+		{`C:`, `C:`, PathDriveRelative},
+		{`\data\..\..\logs\`, `\logs`, PathRooted},
+		{`/`, `\`, PathRooted},
+		// Names stay as they are.
+		{`bin`, `bin`, PathRelative},
+		{`*.tmp`, `*.tmp`, PathRelative},
+		{``, ``, PathRelative},
+	}
+	for _, test := range tests {
+		if have := NormalizePath(test.path); have != test.want {
+			t.Errorf("%s: have: %s, want: %s", test.path, have, test.want)
+		}
+		if kind := PathKindOf(test.path); kind != test.kind {
+			t.Errorf("%s: kind %s, want: %s", test.path, kind, test.kind)
+		}
+		if again := NormalizePath(test.want); again != test.want {
+			t.Errorf("%s: normalized again to %s", test.want, again)
+		}
+	}
+}
+
+func TestNormalizePaths(t *testing.T) {
+	r := NewRobocopy(`//fileserver/share/projects/`, `D:/backup/projects/`, "*.*")
+	r.SetFileSelectionOptions(&FileSelectionOptions{Xd: []string{"bin", `//fileserver/share/projects/tmp/`}, Xf: []string{"*.tmp", `C:/data/./big.iso`}})
+	r.NormalizePaths()
+	want := []string{`\\fileserver\share\projects`, `D:\backup\projects`, "*.*", "/xf", "*.tmp", `C:\data\big.iso`, "/xd", "bin", `\\fileserver\share\projects\tmp`}
+	if have := r.GetCommandArgs(); !slices.Equal(have, want) {
+		t.Errorf("have: %q\nwant: %q", have, want)
+	}
+}
+
+func TestValidateLongPaths(t *testing.T) {
+	long := `\\fileserver\share\` + strings.Repeat(`directory\`, 30)
+	tests := []struct {
+		source, destination string
+		invalid             int
+	}{
+		{`C:\data`, `D:\backup`, 0},
+		{long, `D:\backup`, 1},
+		{`C:\data`, `\\?\D:\backup`, 1},
+		{`\\?\UNC\fileserver\share`, long, 2},
+	}
+	for _, test := range tests {
+		r := NewRobocopy(test.source, test.destination)
+		r.SetCopyOptions(&CopyOptions{NoMoreThan256: true})
+		var invalid int
+		if err := r.Validate(); err != nil {
+			for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var verr *ValidationError
+				if errors.As(err, &verr) && verr.Field == "CopyOptions.NoMoreThan256" {
+					invalid++
+				}
+			}
+		}
+		if invalid != test.invalid {
+			t.Errorf("%s %s: have %d errors, want: %d", test.source, test.destination, invalid, test.invalid)
+		}
+		r.SetCopyOptions(&CopyOptions{})
+		if err := r.Validate(); err != nil {
+			t.Errorf("%s %s without /256: %v", test.source, test.destination, err)
+		}
+	}
+}
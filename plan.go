@@ -0,0 +1,199 @@
+package gorobocopy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+// PlanCategory classifies a planned entry the way robocopy classifies files.
+type PlanCategory string
+
+const (
+	PlanNew      PlanCategory = "new"      // Exists in the source only.
+	PlanNewer    PlanCategory = "newer"    // The source is newer than the destination.
+	PlanOlder    PlanCategory = "older"    // The source is older than the destination.
+	PlanChanged  PlanCategory = "changed"  // Same time stamp, different size.
+	PlanTweaked  PlanCategory = "tweaked"  // Same size and time stamp, different attributes.
+	PlanSame     PlanCategory = "same"     // Identical in the source and destination.
+	PlanLonely   PlanCategory = "lonely"   // Exists in the source only and is excluded by /xl.
+	PlanExtra    PlanCategory = "extra"    // Exists in the destination only.
+	PlanMismatch PlanCategory = "mismatch" // A file on one side and a directory on the other.
+	PlanExcluded PlanCategory = "excluded" // Excluded by a name, size, age or attribute filter.
+)
+
+// PlanAction is what a run would do with an entry.
+type PlanAction string
+
+const (
+	PlanCopy   PlanAction = "copy"   // Copy to the destination, overwriting it unless the category is new.
+	PlanDelete PlanAction = "delete" // Delete from the destination (/purge or /mir).
+	PlanSkip   PlanAction = "skip"   // Leave it alone.
+)
+
+// PlanEntry is a file or directory the run would come across.
+type PlanEntry struct {
+	Category PlanCategory `json:"category"`
+	Action   PlanAction   `json:"action"`
+	Class    string       `json:"class"` // The class as printed by robocopy, e.g. New File.
+	Path     string       `json:"path"`  // Full path, in the destination for extra entries.
+	Dir      bool         `json:"dir,omitempty"`
+	Size     int64        `json:"size"` // Size in bytes, 0 for directories.
+}
+
+// PlanTotal sums up the entries of one category.
+type PlanTotal struct {
+	Files int64 `json:"files"`
+	Dirs  int64 `json:"dirs"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Plan is the preview of a run returned by Robocopy.Plan.
+type Plan struct {
+	Source      string                     `json:"source"`
+	Destination string                     `json:"destination"`
+	Entries     []PlanEntry                `json:"entries"`
+	Totals      map[PlanCategory]PlanTotal `json:"totals"`
+	CopyBytes   int64                      `json:"copyBytes"`   // Estimated number of bytes to copy.
+	DeleteBytes int64                      `json:"deleteBytes"` // Number of bytes in files to delete.
+	ExitCode    ExitCode                   `json:"exitCode"`    // The exit code of the listing run.
+	Summary     *output.Summary            `json:"summary,omitempty"`
+}
+
+// Plan lists what a run would do without changing anything. It runs the command with the
+// executor, adding /l (list only), /v, /bytes and /np to the logging options and removing
+// the ones that suppress or redirect the listing. /save and /quit are left out, as they
+// would write the job file and list nothing. With the engine's executor the trees are
+// walked in Go, otherwise robocopy does the listing. The receiver is not modified.
+func (r *Robocopy) Plan(ctx context.Context) (*Plan, error) {
+	clone := *r
+	lopt := LoggingOptions{}
+	if r.loggingOpt != nil {
+		lopt = *r.loggingOpt
+	}
+	clone.loggingOpt = &LoggingOptions{L: true, V: true, Bytes: true, Np: true, X: lopt.X, Ts: lopt.Ts}
+	if jopt := r.jobOpt; jopt != nil {
+		// /job stays, as the job file holds options of the run.
+		clone.jobOpt = &JobOptions{Job: jopt.Job, Nosd: jopt.Nosd, Nodd: jopt.Nodd, If: jopt.If}
+	}
+	var stdout bytes.Buffer
+	result, err := clone.RunContext(ctx, RunOptions{Stdout: &stdout})
+	if err != nil {
+		return nil, err
+	}
+	events, err := output.Parse(&stdout)
+	if err != nil {
+		return nil, err
+	}
+	purge := r.copyOpt != nil && (r.copyOpt.Purge || r.copyOpt.Mir)
+	plan := &Plan{
+		Source:      r.source,
+		Destination: r.destination,
+		Entries:     []PlanEntry{},
+		Totals:      map[PlanCategory]PlanTotal{},
+		ExitCode:    result.ExitCode,
+		Summary:     result.Summary,
+	}
+	for _, event := range events {
+		var entry PlanEntry
+		switch e := event.(type) {
+		case output.HeaderEvent:
+			plan.Source, plan.Destination = e.Source, e.Destination
+			continue
+		case output.DirEvent:
+			if e.Class == "" {
+				continue // existing directory
+			}
+			entry = PlanEntry{Class: e.Class, Path: e.Path, Dir: true}
+		case output.FileEvent:
+			entry = PlanEntry{Class: e.Class, Path: e.Path, Size: max(e.Size, 0)}
+		default:
+			continue
+		}
+		if entry.Class == "" {
+			continue
+		}
+		entry.Category, entry.Action = classifyPlanEntry(entry.Class, purge)
+		plan.add(entry)
+	}
+	return plan, nil
+}
+
+// Reports whether robocopy copies the entries of a class. Robocopy capitalizes the
+// classes of the files it copies and prints the skipped ones in lowercase.
+func copiesClass(class string) bool {
+	return class != "" && class[:1] != strings.ToLower(class[:1])
+}
+
+// Maps a robocopy class to a category and action.
+func classifyPlanEntry(class string, purge bool) (PlanCategory, PlanAction) {
+	action := PlanSkip
+	if copiesClass(class) {
+		action = PlanCopy
+	}
+	switch strings.ToLower(class) {
+	case "new file", "new dir":
+		return PlanNew, action
+	case "newer":
+		return PlanNewer, action
+	case "older":
+		return PlanOlder, action
+	case "changed":
+		return PlanChanged, action
+	case "tweaked":
+		return PlanTweaked, action
+	case "same":
+		return PlanSame, action
+	case "lonely":
+		return PlanLonely, PlanSkip
+	case "*extra file", "*extra dir":
+		if purge {
+			return PlanExtra, PlanDelete
+		}
+		return PlanExtra, PlanSkip
+	case "*mismatch":
+		return PlanMismatch, PlanSkip
+	}
+	return PlanExcluded, PlanSkip
+}
+
+func (p *Plan) add(entry PlanEntry) {
+	p.Entries = append(p.Entries, entry)
+	total := p.Totals[entry.Category]
+	if entry.Dir {
+		total.Dirs++
+	} else {
+		total.Files++
+	}
+	total.Bytes += entry.Size
+	p.Totals[entry.Category] = total
+	switch entry.Action {
+	case PlanCopy:
+		p.CopyBytes += entry.Size
+	case PlanDelete:
+		p.DeleteBytes += entry.Size
+	}
+}
+
+// Returns the plan as a table with one line per entry, followed by the totals.
+func (p *Plan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan: %s -> %s\n", p.Source, p.Destination)
+	for _, e := range p.Entries {
+		size := fmt.Sprint(e.Size)
+		if e.Dir {
+			size = "dir"
+		}
+		fmt.Fprintf(&b, "%-6s  %-8s  %12s  %s\n", e.Action, e.Category, size, e.Path)
+	}
+	for _, category := range []PlanCategory{PlanNew, PlanNewer, PlanOlder, PlanChanged, PlanTweaked, PlanSame, PlanLonely, PlanExtra, PlanMismatch, PlanExcluded} {
+		if total, ok := p.Totals[category]; ok {
+			fmt.Fprintf(&b, "%-8s  %d files, %d dirs, %d bytes\n", category, total.Files, total.Dirs, total.Bytes)
+		}
+	}
+	fmt.Fprintf(&b, "%d bytes to copy, %d bytes to delete\n", p.CopyBytes, p.DeleteBytes)
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package gorobocopy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/robocopytest"
+)
+
+const listing = "\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"   ROBOCOPY     ::     Robust File Copy for Windows\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"  Started : Monday, January 1, 2024 10:00:00 AM\r\n" +
+	"   Source : C:\\source\\\r\n" +
+	"     Dest : D:\\dest\\\r\n" +
+	"\r\n" +
+	"    Files : *.*\r\n" +
+	"\r\n" +
+	"  Options : *.* /V /L /S /E /DCOPY:DA /COPY:DAT /PURGE /MIR /R:1000000 /W:30\r\n" +
+	"\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"\t                   4\tC:\\source\\\r\n" +
+	"\t    New File  \t\t     100\ta.txt\r\n" +
+	"\t    Newer     \t\t    2048\tb.txt\r\n" +
+	"\t    same      \t\t      10\tc.txt\r\n" +
+	"\t    named     \t\t       5\td.tmp\r\n" +
+	"\t  *EXTRA File \t\t      20\told.txt\r\n" +
+	"\t  New Dir          1\tC:\\source\\sub\\\r\n" +
+	"\t    New File  \t\t       7\te.txt\r\n" +
+	"\t*EXTRA Dir        -1\tD:\\dest\\gone\\\r\n"
+
+func TestPlan(t *testing.T) {
+	fake := robocopytest.New(robocopytest.Response{Stdout: listing, ExitCode: gorobocopy.FilesCopied | gorobocopy.ExtrasDetected})
+	r := gorobocopy.NewRobocopy("C:\\source", "D:\\dest", "*.*")
+	r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+	r.SetLoggingOptions(&gorobocopy.LoggingOptions{Log: "C:\\logs\\run.log", Nfl: true})
+	r.SetExecutor(fake)
+	plan, err := r.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := fake.Calls()[0]
+	if !slices.Contains(args, "/l") || !slices.Contains(args, "/v") || slices.Contains(args, "/nfl") || slices.Contains(args, "/log:C:\\logs\\run.log") {
+		t.Errorf("unexpected arguments %q", args)
+	}
+	if r.GetLoggingOptions().L {
+		t.Error("the receiver was modified")
+	}
+
+	want := []gorobocopy.PlanEntry{
+		{Category: gorobocopy.PlanNew, Action: gorobocopy.PlanCopy, Class: "New File", Path: "C:\\source\\a.txt", Size: 100},
+		{Category: gorobocopy.PlanNewer, Action: gorobocopy.PlanCopy, Class: "Newer", Path: "C:\\source\\b.txt", Size: 2048},
+		{Category: gorobocopy.PlanSame, Action: gorobocopy.PlanSkip, Class: "same", Path: "C:\\source\\c.txt", Size: 10},
+		{Category: gorobocopy.PlanExcluded, Action: gorobocopy.PlanSkip, Class: "named", Path: "C:\\source\\d.tmp", Size: 5},
+		{Category: gorobocopy.PlanExtra, Action: gorobocopy.PlanDelete, Class: "*EXTRA File", Path: "D:\\dest\\old.txt", Size: 20},
+		{Category: gorobocopy.PlanNew, Action: gorobocopy.PlanCopy, Class: "New Dir", Path: "C:\\source\\sub\\", Dir: true},
+		{Category: gorobocopy.PlanNew, Action: gorobocopy.PlanCopy, Class: "New File", Path: "C:\\source\\sub\\e.txt", Size: 7},
+		{Category: gorobocopy.PlanExtra, Action: gorobocopy.PlanDelete, Class: "*EXTRA Dir", Path: "D:\\dest\\gone\\", Dir: true},
+	}
+	if !reflect.DeepEqual(want, plan.Entries) {
+		t.Errorf("have: %+v\nwant: %+v", plan.Entries, want)
+	}
+	if plan.CopyBytes != 2155 || plan.DeleteBytes != 20 || plan.Totals[gorobocopy.PlanNew] != (gorobocopy.PlanTotal{Files: 2, Dirs: 1, Bytes: 107}) {
+		t.Errorf("unexpected totals %d, %d, %+v", plan.CopyBytes, plan.DeleteBytes, plan.Totals)
+	}
+	if plan.Source != "C:\\source\\" || plan.ExitCode != gorobocopy.FilesCopied|gorobocopy.ExtrasDetected {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded gorobocopy.Plan
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(plan, &decoded) {
+		t.Errorf("JSON round trip: %v\n%s", err, data)
+	}
+	if s := plan.String(); !strings.Contains(s, "delete  extra") || !strings.Contains(s, "2155 bytes to copy, 20 bytes to delete") {
+		t.Errorf("unexpected text:\n%s", s)
+	}
+}
+
+func TestPlanJobOptions(t *testing.T) {
+	fake := robocopytest.New(robocopytest.Response{Stdout: listing, ExitCode: gorobocopy.FilesCopied | gorobocopy.ExtrasDetected})
+	r := gorobocopy.NewRobocopy("C:\\source", "D:\\dest")
+	r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+	r.SetJobOptions(&gorobocopy.JobOptions{Job: "base", Save: "nightly", Quit: true})
+	r.SetExecutor(fake)
+	if _, err := r.Plan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	args := fake.Calls()[0]
+	if !slices.Contains(args, "/job:base") || slices.Contains(args, "/save:nightly") || slices.Contains(args, "/quit") {
+		t.Errorf("unexpected arguments %q", args)
+	}
+	if jopt := r.GetJobOptions(); jopt.Save != "nightly" || !jopt.Quit {
+		t.Error("the receiver was modified")
+	}
+
+	// The deletions are counted on a listing without /quit.
+	limit := 1
+	_, err := r.RunContext(context.Background(), gorobocopy.RunOptions{MaxDeletions: &limit})
+	if !errors.Is(err, gorobocopy.ErrTooManyDeletions) {
+		t.Errorf("have: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package robocopytest provides a fake gorobocopy.Executor for testing code that drives
+// robocopy without running it, on any platform.
+package robocopytest
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+)
+
+// Response is what the fake does for one run.
+type Response struct {
+	Stdout   string              // Written to the command's stdout.
+	Stderr   string              // Written to the command's stderr.
+	ExitCode gorobocopy.ExitCode // The exit code of the run.
+	// How long the run takes after writing the output. The run ends early with the
+	// context error if the context is done in the meantime.
+	Delay time.Duration
+	// If set, returned by Execute without writing any output, e.g. to simulate robocopy
+	// not being found.
+	Err error
+}
+
+// Fake is a gorobocopy.Executor that records the arguments of every run and replays
+// scripted responses. It is safe for concurrent use.
+type Fake struct {
+	// Responses are replayed in order, one per run. Once they run out the last one is
+	// repeated. Without any responses every run succeeds with exit code 0 and no output.
+	Responses []Response
+	// Respond, if set, is used instead of Responses to choose the response from the
+	// arguments of the run.
+	Respond func(args []string) Response
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+// New returns a fake replaying the given responses.
+func New(responses ...Response) *Fake {
+	return &Fake{Responses: responses}
+}
+
+func (f *Fake) Execute(ctx context.Context, cmd gorobocopy.Command) (gorobocopy.ExitCode, error) {
+	f.mu.Lock()
+	n := len(f.calls)
+	f.calls = append(f.calls, slices.Clone(cmd.Args))
+	var response Response
+	switch {
+	case f.Respond != nil:
+		response = f.Respond(cmd.Args)
+	case len(f.Responses) != 0:
+		response = f.Responses[min(n, len(f.Responses)-1)]
+	}
+	f.mu.Unlock()
+
+	if response.Err != nil {
+		return -1, response.Err
+	}
+	if _, err := io.WriteString(cmd.Stdout, response.Stdout); err != nil {
+		return -1, err
+	}
+	if _, err := io.WriteString(cmd.Stderr, response.Stderr); err != nil {
+		return -1, err
+	}
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		}
+	}
+	return response.ExitCode, nil
+}
+
+// Calls returns the arguments of every run so far, in order.
+func (f *Fake) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
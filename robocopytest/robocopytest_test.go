@@ -0,0 +1,74 @@
+package robocopytest
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+)
+
+const summary = `
+------------------------------------------------------------------------------
+
+               Total    Copied   Skipped  Mismatch    FAILED    Extras
+    Dirs :         1         0         1         0         0         0
+   Files :         2         1         0         0         1         0
+   Bytes :       300       100         0         0       200         0
+   Times :   0:00:00   0:00:00                       0:00:00   0:00:00
+   Ended : Monday, January 1, 2024 10:00:01 AM
+`
+
+func TestFake(t *testing.T) {
+	fake := New(
+		Response{Stdout: summary, Stderr: "warning", ExitCode: gorobocopy.FilesCopied | gorobocopy.CopyFailures},
+		Response{ExitCode: gorobocopy.AlreadyExist},
+	)
+	cmd := gorobocopy.NewRobocopy("C:\\source", "D:\\destination", "*.*")
+	cmd.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+	cmd.SetExecutor(fake)
+
+	var stdout strings.Builder
+	result, err := cmd.RunContext(context.Background(), gorobocopy.RunOptions{Stdout: &stdout})
+	var exitErr *gorobocopy.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 9 {
+		t.Fatalf("have: %v, want an *ExitError with code 9", err)
+	}
+	if result.Summary == nil || result.Summary.Files.Failed != 1 || result.Stderr != "warning" || stdout.String() != summary {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if cmd.GetExitCode() != 9 || cmd.GetSummary() != result.Summary {
+		t.Errorf("exit code or summary not stored: %d %+v", cmd.GetExitCode(), cmd.GetSummary())
+	}
+
+	if err := cmd.Run(nil, nil, nil); err != nil || cmd.GetExitCode() != gorobocopy.AlreadyExist {
+		t.Errorf("have: %v, %d", err, cmd.GetExitCode())
+	}
+	want := [][]string{cmd.GetCommandArgs(), cmd.GetCommandArgs()}
+	if have := fake.Calls(); !slices.EqualFunc(want, have, slices.Equal) {
+		t.Errorf("have: %q, want: %q", have, want)
+	}
+}
+
+func TestFakeStartFailure(t *testing.T) {
+	cmd := gorobocopy.NewRobocopy("C:\\source", "D:\\destination", "*.*")
+	cmd.SetExecutor(New(Response{Err: exec.ErrNotFound}))
+	if result, err := cmd.RunContext(context.Background(), gorobocopy.RunOptions{}); result != nil || !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("have: %v, %v, want: nil, %v", result, err, exec.ErrNotFound)
+	}
+}
+
+func TestFakeCancellation(t *testing.T) {
+	cmd := gorobocopy.NewRobocopy("C:\\source", "D:\\destination", "*.*")
+	cmd.SetExecutor(New(Response{Delay: time.Hour}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	result, err := cmd.RunContext(ctx, gorobocopy.RunOptions{})
+	if result == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("have: %v, %v, want a result and %v", result, err, context.DeadlineExceeded)
+	}
+}
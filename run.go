@@ -0,0 +1,121 @@
+package gorobocopy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+// DefaultGracePeriod is how long RunContext waits for robocopy to exit after asking it
+// to stop, before killing it, when RunOptions.GracePeriod is not set.
+const DefaultGracePeriod = 10 * time.Second
+
+// RunOptions configures RunContext. The zero value is valid.
+type RunOptions struct {
+	// Stdin, Stdout and Stderr of robocopy. Nil means the null device.
+	// Stderr is captured in Result.Stderr either way.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// How long to wait for robocopy to exit after the context is done before killing it.
+	GracePeriod time.Duration
+	// Turns off the safety checks of CheckSafety for commands that delete or move files.
+	Unsafe bool
+	// The most files and directories /mir or /purge may delete, the contents of extra
+	// directories included. If set, the command is run with /l first, as Plan does, and
+	// refused with ErrTooManyDeletions if it would delete more. Nil means no limit and a
+	// limit of zero refuses any deletion.
+	MaxDeletions *int
+}
+
+// Result describes a finished robocopy run.
+type Result struct {
+	ExitCode ExitCode
+	Duration time.Duration
+	// The job summary robocopy printed, nil if it didn't print one to stdout.
+	Summary *output.Summary
+	// Everything robocopy wrote to stderr.
+	Stderr string
+}
+
+// RunContext validates the options, runs robocopy and waits for it to finish.
+//
+// Commands that delete or move files are refused with a *SafetyError if CheckSafety
+// fails, or if they would delete more than RunOptions.MaxDeletions.
+//
+// The command is run by the executor set with SetExecutor, robocopy itself by default.
+// When the context is done robocopy is asked to stop (with a Ctrl+Break) and killed if
+// it is still running after the grace period. In that case the context error is
+// returned together with the result. A context done after robocopy exited is ignored.
+// If robocopy can't be started, e.g. because it is not on the PATH, an error is returned
+// instead of a result. If robocopy exits with an exit code of 8 or higher, the result is
+// returned along with an *ExitError. The exit code and summary are also available from
+// GetExitCode and GetSummary afterwards.
+func (r *Robocopy) RunContext(ctx context.Context, opts RunOptions) (*Result, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if !opts.Unsafe {
+		if err := r.CheckSafety(); err != nil {
+			return nil, err
+		}
+	}
+	return r.run(ctx, opts)
+}
+
+// Runs the command once it is validated and checked for safety.
+func (r *Robocopy) run(ctx context.Context, opts RunOptions) (*Result, error) {
+	if opts.MaxDeletions != nil {
+		if err := r.checkDeletions(ctx, *opts.MaxDeletions); err != nil {
+			return nil, err
+		}
+	}
+	executor := r.executor
+	if executor == nil {
+		executor = ExecExecutor{}
+	}
+	summary := newSummaryWriter()
+	var stderr bytes.Buffer
+	cmd := Command{
+		Args:        r.GetCommandArgs(),
+		Stdin:       opts.Stdin,
+		Stdout:      summary,
+		Stderr:      &stderr,
+		GracePeriod: opts.GracePeriod,
+	}
+	if opts.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(opts.Stdout, summary)
+	}
+	if opts.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(opts.Stderr, &stderr)
+	}
+	if cmd.GracePeriod <= 0 {
+		cmd.GracePeriod = DefaultGracePeriod
+	}
+
+	start := time.Now()
+	code, err := executor.Execute(ctx, cmd)
+	result := &Result{
+		ExitCode: code,
+		Duration: time.Since(start),
+		Summary:  summary.Close(),
+		Stderr:   stderr.String(),
+	}
+	if err != nil {
+		// The executor only returns the context error if the run was stopped by it.
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			r.exitCode, r.summary = result.ExitCode, result.Summary
+			return result, ctxErr
+		}
+		return nil, err
+	}
+	r.exitCode, r.summary = result.ExitCode, result.Summary
+	if !result.ExitCode.IsSuccess() {
+		return result, &ExitError{Code: result.ExitCode}
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package gorobocopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestRunContextStartFailure(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	r := NewRobocopy("C:\\source", "D:\\destination", "*.*")
+	result, err := r.RunContext(context.Background(), RunOptions{})
+	if result != nil || !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("have: %v, %v, want: nil, %v", result, err, exec.ErrNotFound)
+	}
+	if err := r.Run(nil, nil, nil); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("have: %v, want: %v", err, exec.ErrNotFound)
+	}
+}
+
+// Cancels the context of the run just before returning.
+type cancelingExecutor struct {
+	cancel context.CancelFunc
+	err    error
+}
+
+func (e cancelingExecutor) Execute(ctx context.Context, cmd Command) (ExitCode, error) {
+	e.cancel()
+	return FilesCopied, e.err
+}
+
+func TestRunContextCanceledAfterExit(t *testing.T) {
+	started := errors.New("can't start")
+	tests := []struct {
+		err    error // returned by the executor
+		want   error
+		result bool
+	}{
+		{nil, nil, true},
+		{context.Canceled, context.Canceled, true},
+		{fmt.Errorf("stopping: %w", context.Canceled), context.Canceled, true},
+		// Other errors are kept even with the context done.
+		{started, started, false},
+	}
+	for _, test := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := NewRobocopy("C:\\source", "D:\\destination")
+		r.SetExecutor(cancelingExecutor{cancel: cancel, err: test.err})
+		result, err := r.RunContext(ctx, RunOptions{})
+		if !errors.Is(err, test.want) || test.want == nil && err != nil || (result != nil) != test.result || result != nil && result.ExitCode != FilesCopied {
+			t.Errorf("%v: have: %v, %v, want: %v", test.err, result, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package gorobocopy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var runWindowPattern = regexp.MustCompile(`^(\d\d):?(\d\d)-(\d\d):?(\d\d)$`)
+
+// RunWindow is the value of /rh, the hours in which robocopy may start copying files.
+// Start and End are times of day, as the time since midnight in whole minutes. The
+// window wraps past midnight when End is before Start, so 22:00 to 06:00 is a night.
+// The zero value is no window.
+type RunWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// NewRunWindow returns the window between the times of day of start and end.
+func NewRunWindow(start, end time.Time) RunWindow {
+	return RunWindow{Start: clock(start).Truncate(time.Minute), End: clock(end).Truncate(time.Minute)}
+}
+
+// ParseRunWindow parses a window in the hhmm-hhmm form robocopy takes, or hh:mm-hh:mm.
+func ParseRunWindow(s string) (RunWindow, error) {
+	m := runWindowPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return RunWindow{}, fmt.Errorf("gorobocopy: invalid run hours %q, use the hhmm-hhmm form: %w", s, ErrInvalidValue)
+	}
+	var n [4]int
+	for i := range n {
+		n[i], _ = strconv.Atoi(m[i+1])
+	}
+	if n[0] > 23 || n[2] > 23 || n[1] > 59 || n[3] > 59 {
+		return RunWindow{}, fmt.Errorf("gorobocopy: invalid run hours %q, times go from 0000 to 2359: %w", s, ErrInvalidValue)
+	}
+	w := RunWindow{
+		Start: time.Duration(n[0])*time.Hour + time.Duration(n[1])*time.Minute,
+		End:   time.Duration(n[2])*time.Hour + time.Duration(n[3])*time.Minute,
+	}
+	if w.Start == w.End {
+		return RunWindow{}, fmt.Errorf("gorobocopy: invalid run hours %q, the window must end at another time than it starts: %w", s, ErrInvalidValue)
+	}
+	return w, nil
+}
+
+// Returns the time of day of t, as the time since midnight.
+func clock(t time.Time) time.Duration {
+	hour, min, sec := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// Reports whether the window is not set.
+func (w RunWindow) IsZero() bool {
+	return w == RunWindow{}
+}
+
+// Contains reports whether t, in its location, is inside the window. The start is inside
+// and the end is not. Every time is inside the zero window.
+func (w RunWindow) Contains(t time.Time) bool {
+	if w.IsZero() {
+		return true
+	}
+	now := clock(t)
+	if w.Start < w.End {
+		return now >= w.Start && now < w.End
+	}
+	return now >= w.Start || now < w.End
+}
+
+// NextOpen returns t if it is inside the window, and otherwise when the window opens
+// next, in the location of t.
+func (w RunWindow) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	year, month, day := t.Date()
+	hour, min := int(w.Start/time.Hour), int(w.Start%time.Hour/time.Minute)
+	open := time.Date(year, month, day, hour, min, 0, 0, t.Location())
+	if !open.After(t) {
+		open = time.Date(year, month, day+1, hour, min, 0, 0, t.Location())
+	}
+	return open
+}
+
+// Returns why robocopy can't take the window, or an empty string if it can.
+func (w RunWindow) invalid() string {
+	for _, t := range []time.Duration{w.Start, w.End} {
+		if t < 0 || t >= 24*time.Hour {
+			return "must be between 00:00 and 23:59"
+		}
+		if t%time.Minute != 0 {
+			return "must be in whole minutes"
+		}
+	}
+	if w.Start == w.End {
+		return "must end at another time than it starts"
+	}
+	return ""
+}
+
+// Returns the time of day as hhmm, or hh:mm with a separator.
+func formatClock(t time.Duration, separator string) string {
+	return fmt.Sprintf("%02d%s%02d", int(t/time.Hour), separator, int(t%time.Hour/time.Minute))
+}
+
+// Returns the window the way robocopy takes it, such as 2200-0600.
+func (w RunWindow) String() string {
+	return formatClock(w.Start, "") + "-" + formatClock(w.End, "")
+}
+
+// MarshalText writes the window such as 22:00-06:00.
+func (w RunWindow) MarshalText() ([]byte, error) {
+	if w.IsZero() {
+		return nil, nil
+	}
+	return []byte(formatClock(w.Start, ":") + "-" + formatClock(w.End, ":")), nil
+}
+
+// UnmarshalText parses the window as ParseRunWindow does. An empty text is no window.
+func (w *RunWindow) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*w = RunWindow{}
+		return nil
+	}
+	window, err := ParseRunWindow(string(text))
+	if err != nil {
+		return err
+	}
+	*w = window
+	return nil
+}
@@ -0,0 +1,72 @@
+package gorobocopy
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRunWindow(t *testing.T) {
+	tests := []struct {
+		text string
+		want RunWindow
+	}{
+		{"0800-1730", RunWindow{Start: 8 * time.Hour, End: 17*time.Hour + 30*time.Minute}},
+		{"2200-0600", RunWindow{Start: 22 * time.Hour, End: 6 * time.Hour}},
+		{"22:00-06:00", RunWindow{Start: 22 * time.Hour, End: 6 * time.Hour}},
+		{"0000-2359", RunWindow{End: 23*time.Hour + 59*time.Minute}},
+	}
+	for _, test := range tests {
+		have, err := ParseRunWindow(test.text)
+		if err != nil || have != test.want {
+			t.Errorf("%q: have: %+v %v, want: %+v", test.text, have, err, test.want)
+		}
+	}
+	for _, text := range []string{"", "8-17", "0800", "2400-0600", "0860-1000", "1000-1000", "10:00 - 12:00"} {
+		if _, err := ParseRunWindow(text); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("%q: have: %v", text, err)
+		}
+	}
+}
+
+func TestRunWindowText(t *testing.T) {
+	w := NewRunWindow(time.Date(2024, 1, 1, 22, 5, 59, 0, time.UTC), time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC))
+	if w.String() != "2205-0600" {
+		t.Errorf("have: %s", w)
+	}
+	text, _ := w.MarshalText()
+	var back RunWindow
+	if err := back.UnmarshalText(text); string(text) != "22:05-06:00" || err != nil || back != w {
+		t.Errorf("have: %s %v %+v", text, err, back)
+	}
+}
+
+func TestRunWindowContains(t *testing.T) {
+	at := func(day, hour, min int) time.Time { return time.Date(2024, 3, day, hour, min, 0, 0, time.UTC) }
+	day := RunWindow{Start: 8 * time.Hour, End: 17 * time.Hour}
+	night := RunWindow{Start: 22 * time.Hour, End: 6 * time.Hour}
+	tests := []struct {
+		window RunWindow
+		t      time.Time
+		inside bool
+		open   time.Time
+	}{
+		{day, at(1, 8, 0), true, at(1, 8, 0)},
+		{day, at(1, 12, 30), true, at(1, 12, 30)},
+		{day, at(1, 17, 0), false, at(2, 8, 0)},
+		{day, at(1, 7, 59), false, at(1, 8, 0)},
+		{night, at(1, 23, 0), true, at(1, 23, 0)},
+		{night, at(1, 5, 59), true, at(1, 5, 59)},
+		{night, at(1, 6, 0), false, at(1, 22, 0)},
+		{night, at(1, 21, 59), false, at(1, 22, 0)},
+		{RunWindow{}, at(1, 3, 0), true, at(1, 3, 0)},
+	}
+	for _, test := range tests {
+		if inside := test.window.Contains(test.t); inside != test.inside {
+			t.Errorf("%s at %s: have: %v", test.window, test.t.Format(time.Kitchen), inside)
+		}
+		if open := test.window.NextOpen(test.t); !open.Equal(test.open) {
+			t.Errorf("%s at %s: opens %v, want: %v", test.window, test.t.Format(time.Kitchen), open, test.open)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package gorobocopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	// The destination is empty, robocopy would take the current directory.
+	ErrNoDestination = errors.New("no destination directory")
+	// The destination is the root of a drive or volume. The roots of network shares are
+	// allowed, as shares are often made for the backups alone.
+	ErrRootDestination = errors.New("the destination is the root of a drive")
+	// The destination of /mir or /purge is relative, such as backup or D:, so it could
+	// resolve to anything up to the root of a drive.
+	ErrRelativeDestination = errors.New("the destination is a relative path")
+	// The destination is a system directory, a directory holding the user profiles or one
+	// of the profiles.
+	ErrSystemDestination = errors.New("the destination is a system directory")
+	// The destination is the source or a directory inside it.
+	ErrDestinationInSource = errors.New("the destination is the source or inside it")
+	// The listing run found more deletions than RunOptions.MaxDeletions allows.
+	ErrTooManyDeletions = errors.New("too many deletions")
+)
+
+// SafetyError is returned when the safety checks refuse to run a command that deletes or
+// moves files. Use errors.Is on it to check the reason against the variables above.
+type SafetyError struct {
+	Switch      string // The switch that makes the command destructive: /mir, /purge, /move or /mov.
+	Destination string // The destination directory as given.
+	Deletions   int    // The deletions found by the listing run, for ErrTooManyDeletions.
+	Err         error  // The reason the command was refused.
+}
+
+func (e *SafetyError) Error() string {
+	if errors.Is(e.Err, ErrTooManyDeletions) {
+		return fmt.Sprintf("gorobocopy: refusing to run %s: %v: %d", e.Switch, e.Err, e.Deletions)
+	}
+	return fmt.Sprintf("gorobocopy: refusing to run %s: %v: %q", e.Switch, e.Err, e.Destination)
+}
+
+func (e *SafetyError) Unwrap() error {
+	return e.Err
+}
+
+// The directories, relative to the root and in lower case, that are refused as
+// destinations. The system directories are refused together with everything inside
+// them, the directories holding the user profiles together with the profiles directly
+// inside them, and the protected ones only themselves.
+var (
+	// Checked on paths rooted at / only, that is on Linux and macOS.
+	posixSystemDirs    = []string{`bin`, `boot`, `dev`, `etc`, `lib`, `lib64`, `proc`, `sbin`, `sys`, `usr`, `system`, `library`}
+	posixProfileDirs   = []string{`home`, `users`}
+	posixProtectedDirs = []string{`root`, `var`, `opt`, `srv`, `mnt`, `media`, `applications`}
+	// Checked on every drive.
+	volumeDirs = []string{`$recycle.bin`, `system volume information`}
+	// Checked on the system drive only.
+	windowsSystemDirs  = append([]string{`windows`, `program files`, `program files (x86)`, `programdata`}, volumeDirs...)
+	windowsProfileDirs = []string{`users`, `documents and settings`}
+)
+
+// Returns the drive Windows is installed on, C: if it isn't known.
+func systemDrive() string {
+	if drive := os.Getenv("SystemDrive"); hasDrive(drive) && len(drive) == 2 {
+		return drive
+	}
+	return "C:"
+}
+
+// Returns the switch that makes the command delete or move files, or an empty string.
+func (r *Robocopy) destructiveSwitch() string {
+	switch c := r.copyOpt; {
+	case c == nil:
+		return ""
+	case c.Mir:
+		return "/mir"
+	case c.Purge:
+		return "/purge"
+	case c.Move:
+		return "/move"
+	case c.Mov:
+		return "/mov"
+	}
+	return ""
+}
+
+// CheckSafety reports whether the command may run. Commands with /mir, /purge, /move or
+// /mov are refused with a *SafetyError if their destination is empty, the root of a
+// drive, a system directory or the source or inside it. Commands with /mir or /purge are
+// also refused if their destination is relative, as D: alone is the current directory
+// of the drive and can be its root. Paths are compared the way
+// Windows does, ignoring case and the kind of separators. The system directories of
+// Linux and macOS are refused on paths rooted at /, the ones of Windows on its system
+// drive, so D:\dev or E:\Library are allowed. Listing runs (/l) change nothing and are
+// always allowed. RunContext and Start call it unless RunOptions.Unsafe is set.
+func (r *Robocopy) CheckSafety() error {
+	sw := r.destructiveSwitch()
+	if sw == "" || r.loggingOpt != nil && r.loggingOpt.L {
+		return nil
+	}
+	refuse := func(err error) error {
+		return &SafetyError{Switch: sw, Destination: r.destination, Err: err}
+	}
+	if strings.TrimSpace(r.destination) == "" {
+		return refuse(ErrNoDestination)
+	}
+	dst := strings.ToLower(NormalizePath(r.destination))
+	kind := PathKindOf(dst)
+	if (kind == PathRelative || kind == PathDriveRelative) && (sw == "/mir" || sw == "/purge") {
+		return refuse(ErrRelativeDestination)
+	}
+	root, rest := splitRoot(dst, kind)
+	rest = strings.TrimLeft(rest, `\`)
+	local := kind == PathLocal || kind == PathRooted || kind == PathExtended && !strings.HasPrefix(root[4:], `unc\`)
+	if local && rest == "" {
+		return refuse(ErrRootDestination)
+	}
+	if local {
+		system, profiles, protected := volumeDirs, []string(nil), []string(nil)
+		drive := strings.TrimPrefix(strings.TrimPrefix(root, `\\?\`), `\\.\`)
+		switch {
+		case kind == PathRooted:
+			system, profiles, protected = posixSystemDirs, posixProfileDirs, posixProtectedDirs
+		case hasDrive(drive) && strings.EqualFold(drive[:2], systemDrive()):
+			system, profiles = windowsSystemDirs, windowsProfileDirs
+		}
+		elems := strings.Split(rest, `\`)
+		switch {
+		case slices.Contains(system, elems[0]),
+			slices.Contains(profiles, elems[0]) && len(elems) <= 2,
+			slices.Contains(protected, elems[0]) && len(elems) == 1:
+			return refuse(ErrSystemDestination)
+		}
+	}
+	if src := strings.ToLower(NormalizePath(r.source)); src != "" && src != "." && (dst == src || strings.HasPrefix(dst, strings.TrimSuffix(src, `\`)+`\`)) {
+		return refuse(ErrDestinationInSource)
+	}
+	return nil
+}
+
+// Runs the command with /l first and refuses it if it would delete more files and
+// directories than the limit. Only /mir and /purge delete from the destination. The
+// contents of the extra directories are counted by walking them.
+func (r *Robocopy) checkDeletions(ctx context.Context, limit int) error {
+	if c := r.copyOpt; c == nil || !c.Mir && !c.Purge || r.loggingOpt != nil && r.loggingOpt.L {
+		return nil
+	}
+	plan, err := r.Plan(ctx)
+	if err != nil {
+		return err
+	}
+	deletions := 0
+	for _, entry := range plan.Entries {
+		switch {
+		case entry.Action != PlanDelete:
+		case entry.Dir:
+			// Robocopy lists an extra directory without its contents, which go with it.
+			n, err := countTree(entry.Path)
+			if err != nil {
+				return fmt.Errorf("gorobocopy: counting the deletions: %w", err)
+			}
+			deletions += n
+		default:
+			deletions++
+		}
+	}
+	if deletions > limit {
+		return &SafetyError{Switch: r.destructiveSwitch(), Destination: r.destination, Deletions: deletions, Err: ErrTooManyDeletions}
+	}
+	return nil
+}
+
+// Returns the number of files and directories in the tree, the directory included. A
+// directory that doesn't exist counts as one, as robocopy still reported it.
+func countTree(dir string) (int, error) {
+	n := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	if n == 0 && errors.Is(err, fs.ErrNotExist) {
+		return 1, nil
+	}
+	return n, err
+}
@@ -0,0 +1,192 @@
+package gorobocopy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gorobocopy "github.com/aggellos2001/go-robocopy"
+	"github.com/aggellos2001/go-robocopy/robocopytest"
+)
+
+func TestCheckSafety(t *testing.T) {
+	tests := []struct {
+		source, destination string
+		opts                gorobocopy.CopyOptions
+		err                 error
+	}{
+		{`C:\data`, `D:\backup\data`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `\\backup\data`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `\\backup\data\`, gorobocopy.CopyOptions{Purge: true}, nil},
+		{`/srv/data`, `/mnt/backup/data`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `D:\Users\Public\Documents\backup`, gorobocopy.CopyOptions{Mir: true}, nil},
+		// Only /mir, /purge, /move and /mov are checked.
+		{`C:\data`, `D:\`, gorobocopy.CopyOptions{E: true}, nil},
+		{`C:\data`, ``, gorobocopy.CopyOptions{Mov: true}, gorobocopy.ErrNoDestination},
+		{`C:\data`, ``, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrNoDestination},
+		{`C:\data`, `  `, gorobocopy.CopyOptions{Move: true}, gorobocopy.ErrNoDestination},
+		{`C:\data`, `D:\`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrRootDestination},
+		{`C:\data`, `d:`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrRelativeDestination},
+		{`C:\data`, `d:backup`, gorobocopy.CopyOptions{Purge: true}, gorobocopy.ErrRelativeDestination},
+		{`C:\data`, `backup\data`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrRelativeDestination},
+		{`C:\data`, `.`, gorobocopy.CopyOptions{Purge: true}, gorobocopy.ErrRelativeDestination},
+		// /move and /mov don't delete from the destination.
+		{`C:\data`, `backup\data`, gorobocopy.CopyOptions{Mov: true}, nil},
+		{`C:\data`, `D:/backup/..`, gorobocopy.CopyOptions{Purge: true}, gorobocopy.ErrRootDestination},
+		{`C:\data`, `\\?\D:\`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrRootDestination},
+		{`/srv/data`, `/`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrRootDestination},
+		{`C:\data`, `C:\Windows`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`C:\data`, `c:\windows\system32\`, gorobocopy.CopyOptions{Purge: true}, gorobocopy.ErrSystemDestination},
+		{`C:\data`, `C:\Program Files (x86)\App`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`C:\data`, `C:\Users\alex`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`C:\data`, `\\?\C:\Users`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`/srv/data`, `/etc`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`/srv/data`, `/home/alex/`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`/srv/data`, `/var`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`/srv/data`, `/var/backups/data`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `C:\Data\`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrDestinationInSource},
+		{`C:\data`, `c:/data/backup`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrDestinationInSource},
+		{`\\fileserver\share`, `\\FILESERVER\share\copy`, gorobocopy.CopyOptions{Move: true}, gorobocopy.ErrDestinationInSource},
+		{`C:\data`, `C:\data2`, gorobocopy.CopyOptions{Mir: true}, nil},
+		// The POSIX names are only refused on paths rooted at /, the Windows ones on the
+		// system drive.
+		{`C:\data`, `D:\dev\backup`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `E:\Library\Music`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `D:\bin\tools`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `C:\etc`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `D:\var`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `D:\Windows`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `D:\Users\alex`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `\\?\E:\Program Files`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`/srv/data`, `/windows`, gorobocopy.CopyOptions{Mir: true}, nil},
+		{`C:\data`, `D:\$RECYCLE.BIN\x`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+		{`C:\data`, `E:\System Volume Information`, gorobocopy.CopyOptions{Mir: true}, gorobocopy.ErrSystemDestination},
+	}
+	t.Setenv("SystemDrive", "C:")
+	for _, test := range tests {
+		r := gorobocopy.NewRobocopy(test.source, test.destination)
+		r.SetCopyOptions(&test.opts)
+		err := r.CheckSafety()
+		var serr *gorobocopy.SafetyError
+		if test.err == nil && err != nil || test.err != nil && (!errors.Is(err, test.err) || !errors.As(err, &serr) || serr.Destination != test.destination) {
+			t.Errorf("%s %s: have: %v, want: %v", test.source, test.destination, err, test.err)
+		}
+	}
+}
+
+func TestCheckSafetySystemDrive(t *testing.T) {
+	t.Setenv("SystemDrive", "D:")
+	tests := []struct {
+		destination string
+		err         error
+	}{
+		{`D:\Windows\Temp`, gorobocopy.ErrSystemDestination},
+		{`d:\users\alex`, gorobocopy.ErrSystemDestination},
+		{`C:\Windows`, nil},
+		{`C:\Users\alex`, nil},
+	}
+	for _, test := range tests {
+		r := gorobocopy.NewRobocopy(`E:\data`, test.destination)
+		r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+		if err := r.CheckSafety(); !errors.Is(err, test.err) || test.err == nil && err != nil {
+			t.Errorf("%s: have: %v, want: %v", test.destination, err, test.err)
+		}
+	}
+}
+
+func TestRunSafety(t *testing.T) {
+	fake := robocopytest.New()
+	r := gorobocopy.NewRobocopy(`C:\source`, `C:\source\backup`)
+	r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+	r.SetExecutor(fake)
+	if _, err := r.RunContext(context.Background(), gorobocopy.RunOptions{}); !errors.Is(err, gorobocopy.ErrDestinationInSource) {
+		t.Errorf("have: %v", err)
+	}
+	if _, err := r.Start(context.Background(), gorobocopy.StreamOptions{}); !errors.Is(err, gorobocopy.ErrDestinationInSource) {
+		t.Errorf("start: have: %v", err)
+	}
+	if len(fake.Calls()) != 0 {
+		t.Errorf("ran: %q", fake.Calls())
+	}
+	if _, err := r.RunContext(context.Background(), gorobocopy.RunOptions{Unsafe: true}); err != nil || len(fake.Calls()) != 1 {
+		t.Errorf("unsafe: have: %v, %d runs", err, len(fake.Calls()))
+	}
+}
+
+func TestRunMaxDeletions(t *testing.T) {
+	// The listing deletes old.txt and the gone directory.
+	tests := []struct {
+		limit int
+		runs  int
+		err   error
+	}{
+		{-1, 1, nil}, // no limit
+		{0, 1, gorobocopy.ErrTooManyDeletions},
+		{1, 1, gorobocopy.ErrTooManyDeletions},
+		{2, 2, nil},
+	}
+	for _, test := range tests {
+		fake := robocopytest.New(robocopytest.Response{Stdout: listing, ExitCode: gorobocopy.FilesCopied | gorobocopy.ExtrasDetected})
+		r := gorobocopy.NewRobocopy(`C:\source`, `D:\dest`)
+		r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+		r.SetExecutor(fake)
+		var opts gorobocopy.RunOptions
+		if test.limit >= 0 {
+			opts.MaxDeletions = &test.limit
+		}
+		_, err := r.RunContext(context.Background(), opts)
+		var serr *gorobocopy.SafetyError
+		if test.err == nil && err != nil || test.err != nil && (!errors.As(err, &serr) || !errors.Is(err, test.err) || serr.Deletions != 2) {
+			t.Errorf("%d: have: %v, want: %v", test.limit, err, test.err)
+		}
+		if calls := fake.Calls(); len(calls) != test.runs {
+			t.Errorf("%d: have %d runs, want: %d", test.limit, len(calls), test.runs)
+		}
+	}
+}
+
+func TestRunMaxDeletionsExtraDirs(t *testing.T) {
+	// Three extra directories holding five files each, one of them in a subdirectory.
+	dest := t.TempDir()
+	var listing strings.Builder
+	fmt.Fprintf(&listing, "\t                   0\t%s%c\r\n", dest, filepath.Separator)
+	for _, dir := range []string{"a", "b", "c"} {
+		for _, file := range []string{"1", "2", "3", "4", filepath.Join("sub", "5")} {
+			path := filepath.Join(dest, dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fmt.Fprintf(&listing, "\t*EXTRA Dir        -1\t%s%c\r\n", filepath.Join(dest, dir), filepath.Separator)
+	}
+	tests := []struct {
+		limit int
+		runs  int
+		err   error
+	}{
+		{3, 1, gorobocopy.ErrTooManyDeletions},
+		{20, 1, gorobocopy.ErrTooManyDeletions},
+		{21, 2, nil},
+	}
+	for _, test := range tests {
+		fake := robocopytest.New(robocopytest.Response{Stdout: listing.String(), ExitCode: gorobocopy.ExtrasDetected})
+		r := gorobocopy.NewRobocopy(t.TempDir(), dest)
+		r.SetCopyOptions(&gorobocopy.CopyOptions{Mir: true})
+		r.SetExecutor(fake)
+		_, err := r.RunContext(context.Background(), gorobocopy.RunOptions{MaxDeletions: &test.limit})
+		var serr *gorobocopy.SafetyError
+		if test.err == nil && err != nil || test.err != nil && (!errors.As(err, &serr) || !errors.Is(err, test.err) || serr.Deletions != 21) {
+			t.Errorf("%d: have: %v, want: %v", test.limit, err, test.err)
+		}
+		if calls := fake.Calls(); len(calls) != test.runs {
+			t.Errorf("%d: have %d runs, want: %d", test.limit, len(calls), test.runs)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package gorobocopy
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+// The period the throughput is measured over.
+const throughputWindow = time.Second
+
+// Progress is the state of a job, as far as its output tells.
+type Progress struct {
+	Dir       string // The directory being processed.
+	File      string // The file being copied, empty between files.
+	FileSize  int64  // Size of File in bytes.
+	FileBytes int64  // Bytes of File copied so far, estimated from the percentage.
+	Files     int64  // Number of files copied so far, including File.
+	BytesDone int64  // Bytes copied so far, including FileBytes.
+	// StreamOptions.TotalBytes if set, otherwise the size of the files announced so far.
+	BytesTotal     int64
+	BytesPerSecond float64 // Throughput over the last second.
+	Elapsed        time.Duration
+}
+
+// Event is reported by a Job for every event in the output of robocopy, and once more
+// when the job is finished.
+type Event struct {
+	Output   output.Event // The parsed output event, nil for the final event.
+	Progress Progress     // The progress after the event.
+	// Set on the final event only, the same values Wait returns.
+	Result *Result
+	Err    error
+}
+
+// StreamOptions configures Start and Stream. The zero value is valid.
+type StreamOptions struct {
+	// Used by Start to run robocopy. Stdout receives the raw output as well.
+	RunOptions
+	// The number of bytes the job is expected to copy, e.g. Plan.CopyBytes. Zero to add
+	// up the sizes of the files as robocopy announces them.
+	TotalBytes int64
+	// The callbacks are called by the goroutine reading the output, before the event is
+	// sent to the channel. They must not block for long, as the run waits for them.
+	OnFile     func(file output.FileEvent, progress Progress)
+	OnProgress func(progress Progress)
+	OnError    func(err output.ErrorEvent)
+}
+
+// Job is a robocopy run whose output is parsed while it is being written.
+type Job struct {
+	ctx    context.Context
+	opts   StreamOptions
+	events chan Event
+	done   chan struct{}
+	start  time.Time
+	now    func() time.Time
+
+	mu       sync.Mutex
+	progress Progress
+	samples  []sample // bytes done over the throughput window
+	summary  *output.Summary
+	result   *Result
+	err      error
+}
+
+type sample struct {
+	time  time.Time
+	bytes int64
+}
+
+// Start validates the options, checks their safety with CheckSafety and starts robocopy,
+// returning a job that reports its progress as it runs. The executor set with
+// SetExecutor is used, robocopy itself by default, and the context and the other run
+// options are handled as in RunContext. The events must be read, or Wait called, until
+// the job finishes or the context is done.
+func (r *Robocopy) Start(ctx context.Context, opts StreamOptions) (*Job, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if !opts.Unsafe {
+		if err := r.CheckSafety(); err != nil {
+			return nil, err
+		}
+	}
+	j := newJob(ctx, opts, time.Now)
+	pr, pw := io.Pipe()
+	runOpts := opts.RunOptions
+	runOpts.Stdout = pw
+	if opts.Stdout != nil {
+		runOpts.Stdout = io.MultiWriter(opts.Stdout, pw)
+	}
+	type run struct {
+		result *Result
+		err    error
+	}
+	finished := make(chan run, 1)
+	go func() {
+		result, err := r.run(ctx, runOpts)
+		pw.Close()
+		finished <- run{result, err}
+	}()
+	go func() {
+		j.read(pr)
+		run := <-finished
+		j.finish(run.result, run.err)
+	}()
+	return j, nil
+}
+
+// Stream returns a job reporting the progress of robocopy output read from r, such as a
+// recorded log. The job finishes at the end of r, with the exit code robocopy would have
+// returned for the last summary in the output.
+func Stream(r io.Reader, opts StreamOptions) *Job {
+	j := newJob(context.Background(), opts, time.Now)
+	go func() {
+		j.read(r)
+		j.mu.Lock()
+		result := &Result{Duration: j.now().Sub(j.start), Summary: j.summary}
+		j.mu.Unlock()
+		if result.Summary != nil {
+			result.ExitCode = summaryExitCode(result.Summary)
+		}
+		j.finish(result, nil)
+	}()
+	return j
+}
+
+func newJob(ctx context.Context, opts StreamOptions, now func() time.Time) *Job {
+	j := &Job{
+		ctx:    ctx,
+		opts:   opts,
+		events: make(chan Event, 64),
+		done:   make(chan struct{}),
+		start:  now(),
+		now:    now,
+	}
+	j.progress.BytesTotal = opts.TotalBytes
+	j.samples = []sample{{time: j.start}}
+	return j
+}
+
+// Events returns the channel the events of the job are sent to. It is closed after the
+// final event, which carries the result. Progress events are dropped when the channel
+// is full, all the others wait for the channel to be read, and so does robocopy. Once
+// the context of Start is done the events are dropped when the channel is full, so the
+// job ends even if nobody reads them, but the final event is always sent.
+func (j *Job) Events() <-chan Event {
+	return j.events
+}
+
+// Wait waits for the job to finish and returns its result, as RunContext does. The
+// events that have not been received from Events yet are discarded.
+func (j *Job) Wait() (*Result, error) {
+	for range j.events {
+	}
+	<-j.done
+	return j.result, j.err
+}
+
+// Returns the latest progress of the job.
+func (j *Job) Progress() Progress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress
+}
+
+// Parses the output and reports its events. The rest of the output is drained if the
+// parser gives up, so that robocopy doesn't block.
+func (j *Job) read(r io.Reader) {
+	p := output.NewParser(r)
+	for {
+		event, err := p.Next()
+		if err != nil {
+			break
+		}
+		j.handle(event)
+	}
+	io.Copy(io.Discard, r)
+}
+
+func (j *Job) handle(event output.Event) {
+	j.mu.Lock()
+	p := &j.progress
+	progressed := false
+	switch e := event.(type) {
+	case output.DirEvent:
+		j.endFile()
+		p.Dir = e.Path
+	case output.FileEvent:
+		if p.File != "" && e.Path == p.File {
+			break // listed again after a failed attempt
+		}
+		j.endFile()
+		// Files are listed without a class with /nc.
+		if e.Class == "" || copiesClass(e.Class) {
+			p.File, p.FileSize = e.Path, max(e.Size, 0)
+			p.Files++
+			if j.opts.TotalBytes == 0 {
+				p.BytesTotal += p.FileSize
+			}
+			progressed = true
+		}
+	case output.ProgressEvent:
+		if p.File != "" {
+			bytes := int64(e.Percent / 100 * float64(p.FileSize))
+			p.BytesDone += bytes - p.FileBytes
+			p.FileBytes = bytes
+			progressed = true
+		}
+	case output.ErrorEvent:
+		switch {
+		case p.File != "" && e.Path == p.File:
+			// The copy is started over on the next attempt.
+			p.BytesDone -= p.FileBytes
+			p.FileBytes = 0
+		case p.File != "" && strings.Contains(e.Message, "RETRY LIMIT EXCEEDED"):
+			// The file is given up on.
+			p.BytesDone -= p.FileBytes
+			if j.opts.TotalBytes == 0 {
+				p.BytesTotal -= p.FileSize
+			}
+			p.File, p.FileSize, p.FileBytes = "", 0, 0
+			p.Files--
+		}
+	case output.SummaryEvent:
+		j.endFile()
+		if e.Summary != nil {
+			j.summary = e.Summary
+		}
+	}
+	j.measure()
+	snapshot := *p
+	j.mu.Unlock()
+
+	if file, ok := event.(output.FileEvent); ok && j.opts.OnFile != nil {
+		j.opts.OnFile(file, snapshot)
+	}
+	if progressed && j.opts.OnProgress != nil {
+		j.opts.OnProgress(snapshot)
+	}
+	if err, ok := event.(output.ErrorEvent); ok && j.opts.OnError != nil {
+		j.opts.OnError(err)
+	}
+	if _, ok := event.(output.ProgressEvent); ok {
+		select {
+		case j.events <- Event{Output: event, Progress: snapshot}:
+		default:
+		}
+		return
+	}
+	select {
+	case j.events <- Event{Output: event, Progress: snapshot}:
+	case <-j.ctx.Done():
+		// The events may no longer be read, drop them rather than block the run.
+		select {
+		case j.events <- Event{Output: event, Progress: snapshot}:
+		default:
+		}
+	}
+}
+
+// Counts the rest of the current file as copied. Robocopy doesn't print anything when
+// a file is done, so this happens when the next entry or the summary shows up.
+func (j *Job) endFile() {
+	p := &j.progress
+	if p.File != "" {
+		p.BytesDone += p.FileSize - p.FileBytes
+		p.File, p.FileSize, p.FileBytes = "", 0, 0
+	}
+}
+
+// Updates the elapsed time and the throughput. The oldest sample kept is the last one
+// before the window, so that the throughput covers at least the whole window.
+func (j *Job) measure() {
+	now := j.now()
+	p := &j.progress
+	p.Elapsed = now.Sub(j.start)
+	j.samples = append(j.samples, sample{now, p.BytesDone})
+	for len(j.samples) > 2 && now.Sub(j.samples[1].time) >= throughputWindow {
+		j.samples = j.samples[1:]
+	}
+	if first := j.samples[0]; now.After(first.time) {
+		p.BytesPerSecond = float64(p.BytesDone-first.bytes) / now.Sub(first.time).Seconds()
+	}
+}
+
+func (j *Job) finish(result *Result, err error) {
+	j.mu.Lock()
+	j.endFile()
+	j.measure()
+	j.result, j.err = result, err
+	snapshot := j.progress
+	j.mu.Unlock()
+	final := Event{Progress: snapshot, Result: result, Err: err}
+	select {
+	case j.events <- final:
+	case <-j.ctx.Done():
+		// Makes room for the final event by dropping the oldest one, this goroutine is
+		// the only one sending.
+		select {
+		case j.events <- final:
+		case <-j.events:
+			j.events <- final
+		}
+	}
+	close(j.events)
+	close(j.done)
+}
+
+// Returns the exit code robocopy returns for the counts of the summary.
+func summaryExitCode(s *output.Summary) (code ExitCode) {
+	if s.Files.Copied > 0 {
+		code |= FilesCopied
+	}
+	if s.Files.Extras > 0 || s.Dirs.Extras > 0 {
+		code |= ExtrasDetected
+	}
+	if s.Files.Mismatch > 0 || s.Dirs.Mismatch > 0 {
+		code |= MismatchesDetected
+	}
+	if s.Files.Failed > 0 || s.Dirs.Failed > 0 {
+		code |= CopyFailures
+	}
+	return code
+}
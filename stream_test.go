@@ -0,0 +1,244 @@
+package gorobocopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+const streamLog = "\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"   ROBOCOPY     ::     Robust File Copy for Windows\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"  Started : Monday, January 1, 2024 10:00:00 AM\r\n" +
+	"   Source : C:\\source\\\r\n" +
+	"     Dest : D:\\dest\\\r\n" +
+	"\r\n" +
+	"    Files : *.*\r\n" +
+	"\r\n" +
+	"  Options : *.* /S /E /DCOPY:DA /COPY:DAT /R:1 /W:5\r\n" +
+	"\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"\t                   3\tC:\\source\\\r\n" +
+	"\t    New File  \t\t     100\ta.txt\r\n" +
+	"  0%  \r100%  \r\n" +
+	"\t    New File  \t\t    1000\tlocked.txt\r\n" +
+	"  0%  \r 50%  \r\n" +
+	"2024/01/01 10:00:00 ERROR 32 (0x00000020) Copying File C:\\source\\locked.txt\r\n" +
+	"The process cannot access the file because it is being used by another process.\r\n" +
+	"Waiting 5 seconds... Retrying...\r\n" +
+	"\t    New File  \t\t    1000\tlocked.txt\r\n" +
+	"  0%  \r\n" +
+	"2024/01/01 10:00:05 ERROR 32 (0x00000020) Copying File C:\\source\\locked.txt\r\n" +
+	"The process cannot access the file because it is being used by another process.\r\n" +
+	"\r\n" +
+	"ERROR: RETRY LIMIT EXCEEDED.\r\n" +
+	"\r\n" +
+	"\t    same      \t\t      10\tc.txt\r\n" +
+	"\t  New Dir          1\tC:\\source\\sub\\\r\n" +
+	"\t    Newer     \t\t    2048\tb.txt\r\n" +
+	"  0%  \r 25%  \r 75%  \r100%  \r\n" +
+	"\t  *EXTRA File \t\t      20\told.txt\r\n" +
+	"\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"               Total    Copied   Skipped  Mismatch    FAILED    Extras\r\n" +
+	"    Dirs :         2         1         1         0         0         0\r\n" +
+	"   Files :         4         2         1         0         1         1\r\n" +
+	"   Bytes :      3158      2148        10         0      1000        20\r\n" +
+	"   Times :   0:00:05   0:00:00                       0:00:05   0:00:00\r\n" +
+	"   Ended : Monday, January 1, 2024 10:00:05 AM\r\n"
+
+func TestStream(t *testing.T) {
+	var files []string
+	var progress []int64
+	var failures int
+	job := Stream(strings.NewReader(streamLog), StreamOptions{
+		OnFile:     func(file output.FileEvent, p Progress) { files = append(files, file.Name) },
+		OnProgress: func(p Progress) { progress = append(progress, p.BytesDone) },
+		OnError:    func(err output.ErrorEvent) { failures++ },
+	})
+	var last Event
+	n := 0
+	for event := range job.Events() {
+		last = event
+		n++
+		if p, ok := event.Output.(output.ProgressEvent); ok && p.Path == "C:\\source\\sub\\b.txt" && p.Percent == 75 {
+			if event.Progress.File != p.Path || event.Progress.FileBytes != 1536 || event.Progress.BytesDone != 100+1536 {
+				t.Errorf("75%%: %+v", event.Progress)
+			}
+		}
+	}
+	if last.Output != nil || last.Result == nil || last.Err != nil {
+		t.Fatalf("last event: %+v", last)
+	}
+	if want := FilesCopied | ExtrasDetected | CopyFailures; last.Result.ExitCode != want {
+		t.Errorf("exit code: have: %d, want: %d", last.Result.ExitCode, want)
+	}
+	if last.Result.Summary == nil || last.Result.Summary.Files.Failed != 1 {
+		t.Errorf("summary: %+v", last.Result.Summary)
+	}
+	p := last.Progress
+	if p.Files != 2 || p.BytesDone != 2148 || p.BytesTotal != 2148 || p.File != "" || p.Dir != "C:\\source\\sub\\" {
+		t.Errorf("progress: %+v", p)
+	}
+	if want := []string{"a.txt", "locked.txt", "locked.txt", "c.txt", "b.txt", "old.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files: have: %q, want: %q", files, want)
+	}
+	if want := []int64{0, 0, 100, 100, 100, 600, 100, 100, 100, 612, 1636, 2148}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress: have: %d, want: %d", progress, want)
+	}
+	if failures != 3 {
+		t.Errorf("errors: %d", failures)
+	}
+	result, err := job.Wait()
+	if result != last.Result || err != nil {
+		t.Errorf("wait: %v %v", result, err)
+	}
+	if n < 20 {
+		t.Errorf("only %d events", n)
+	}
+}
+
+func TestStreamTotal(t *testing.T) {
+	job := Stream(strings.NewReader(streamLog), StreamOptions{TotalBytes: 5000})
+	job.Wait()
+	if p := job.Progress(); p.BytesTotal != 5000 || p.BytesDone != 2148 {
+		t.Errorf("progress: %+v", p)
+	}
+}
+
+// Wait must not block on events nobody reads.
+func TestStreamWait(t *testing.T) {
+	var b strings.Builder
+	f := &output.Formatter{W: &b}
+	f.Format(output.DirEvent{Files: 500, Path: "C:\\source\\"})
+	for i := 0; i < 500; i++ {
+		f.Format(output.FileEvent{Class: "New File", Size: 10, Name: fmt.Sprintf("%d.txt", i)})
+		f.Format(output.ProgressEvent{Percent: 100})
+	}
+	done := make(chan *Result)
+	go func() {
+		result, _ := Stream(strings.NewReader(b.String()), StreamOptions{}).Wait()
+		done <- result
+	}()
+	select {
+	case result := <-done:
+		if result.Summary != nil || result.ExitCode != 0 {
+			t.Errorf("result: %+v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait blocked")
+	}
+}
+
+func TestStreamThroughput(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	j := newJob(context.Background(), StreamOptions{}, func() time.Time { return now })
+	steps := []struct {
+		after time.Duration
+		event output.Event
+		rate  float64
+	}{
+		{0, output.FileEvent{Class: "New File", Size: 1000, Path: "a"}, 0},
+		{500 * time.Millisecond, output.ProgressEvent{Percent: 50, Path: "a"}, 1000},
+		{time.Second, output.ProgressEvent{Percent: 100, Path: "a"}, 500},
+		{time.Second, output.FileEvent{Class: "New File", Size: 10, Path: "b"}, 0},
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		j.handle(step.event)
+		<-j.events
+		if p := j.Progress(); p.BytesPerSecond != step.rate {
+			t.Errorf("%d: have: %v, want: %v", i, p.BytesPerSecond, step.rate)
+		}
+	}
+	if p := j.Progress(); p.Elapsed != 2500*time.Millisecond {
+		t.Errorf("elapsed: %v", p.Elapsed)
+	}
+}
+
+// Writes the output in small chunks, as robocopy does.
+type chunkedExecutor struct {
+	stdout string
+	code   ExitCode
+}
+
+func (e chunkedExecutor) Execute(ctx context.Context, cmd Command) (ExitCode, error) {
+	for s := e.stdout; s != ""; {
+		n := min(len(s), 7)
+		cmd.Stdout.Write([]byte(s[:n]))
+		s = s[n:]
+	}
+	return e.code, nil
+}
+
+func TestStart(t *testing.T) {
+	var stdout strings.Builder
+	r := NewRobocopy("C:\\source", "D:\\dest", "*.*")
+	r.SetExecutor(chunkedExecutor{streamLog, CopyFailures | FilesCopied})
+	job, err := r.Start(context.Background(), StreamOptions{RunOptions: RunOptions{Stdout: &stdout}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last Event
+	for event := range job.Events() {
+		last = event
+	}
+	var exitErr *ExitError
+	if last.Result == nil || last.Result.ExitCode != CopyFailures|FilesCopied || !errors.As(last.Err, &exitErr) {
+		t.Errorf("last event: %+v", last)
+	}
+	if last.Progress.Files != 2 || last.Result.Summary == nil {
+		t.Errorf("last event: %+v", last)
+	}
+	if result, err := job.Wait(); result != last.Result || err != last.Err {
+		t.Errorf("wait: %v %v", result, err)
+	}
+	if stdout.String() != streamLog {
+		t.Error("stdout doesn't have the raw output")
+	}
+
+	r.SetCopyOptions(&CopyOptions{Mt: 8, Ipg: 10})
+	if _, err := r.Start(context.Background(), StreamOptions{}); err == nil {
+		t.Error("invalid options: no error")
+	}
+}
+
+// A canceled job must finish even if nobody reads its events.
+func TestStartCanceled(t *testing.T) {
+	var b strings.Builder
+	f := &output.Formatter{W: &b}
+	f.Format(output.DirEvent{Files: 500, Path: "C:\\source\\"})
+	for i := 0; i < 500; i++ {
+		f.Format(output.FileEvent{Class: "New File", Size: 10, Name: fmt.Sprintf("%d.txt", i)})
+	}
+	r := NewRobocopy("C:\\source", "D:\\dest")
+	r.SetExecutor(chunkedExecutor{b.String(), FilesCopied})
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := r.Start(ctx, StreamOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case <-job.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job blocked")
+	}
+	var last Event
+	for event := range job.Events() {
+		last = event
+	}
+	if last.Result == nil || last.Result.ExitCode != FilesCopied {
+		t.Errorf("last event: %+v", last)
+	}
+}
@@ -0,0 +1,46 @@
+package gorobocopy
+
+import (
+	"io"
+
+	"github.com/aggellos2001/go-robocopy/output"
+)
+
+// Parses the output written to it in the background and keeps the last job summary.
+type summaryWriter struct {
+	pw      *io.PipeWriter
+	done    chan struct{}
+	summary *output.Summary
+}
+
+func newSummaryWriter() *summaryWriter {
+	pr, pw := io.Pipe()
+	w := &summaryWriter{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		p := output.NewParser(pr)
+		for {
+			event, err := p.Next()
+			if err != nil {
+				break
+			}
+			if s, ok := event.(output.SummaryEvent); ok && s.Summary != nil {
+				w.summary = s.Summary
+			}
+		}
+		// Keep draining so the writer never blocks if the parser gave up.
+		io.Copy(io.Discard, pr)
+	}()
+	return w
+}
+
+func (w *summaryWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Waits for the parser to finish and returns the summary, or nil if there was none.
+func (w *summaryWriter) Close() *output.Summary {
+	w.pw.Close()
+	<-w.done
+	return w.summary
+}
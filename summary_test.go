@@ -0,0 +1,23 @@
+package gorobocopy
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestSummaryWriter(t *testing.T) {
+	f, err := os.Open("output/testdata/default.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := newSummaryWriter()
+	if _, err := io.Copy(w, f); err != nil {
+		t.Fatal(err)
+	}
+	summary := w.Close()
+	if summary == nil || summary.Files.Copied != 3 || summary.Bytes.Extras != 20 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package gorobocopy
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Asks the process to stop with an interrupt signal on cancellation. Exec kills it if it
+// is still running after the grace period.
+func configureTermination(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+}
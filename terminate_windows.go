@@ -0,0 +1,23 @@
+package gorobocopy
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+var generateConsoleCtrlEvent = syscall.NewLazyDLL("kernel32.dll").NewProc("GenerateConsoleCtrlEvent")
+
+const ctrlBreakEvent = 1
+
+// Starts robocopy in its own process group so that it can be sent a Ctrl+Break on
+// cancellation without affecting this process. If the event can't be delivered (e.g.
+// there is no console) the error makes exec fall back to killing after the grace period.
+func configureTermination(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+	cmd.Cancel = func() error {
+		if ok, _, err := generateConsoleCtrlEvent.Call(ctrlBreakEvent, uintptr(cmd.Process.Pid)); ok == 0 {
+			return err
+		}
+		return nil
+	}
+}
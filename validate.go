@@ -0,0 +1,133 @@
+package gorobocopy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/flags/aflags"
+)
+
+// ValidationError describes an option, or combination of options, that robocopy rejects
+// or handles in a way that is most likely not intended.
+type ValidationError struct {
+	Field  string // The option field at fault, e.g. CopyOptions.Mt
+	Switch string // The robocopy switch the field maps to, e.g. /mt
+	Reason string // Human readable description of the problem.
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("gorobocopy: invalid %s (%s): %s", e.Switch, e.Field, e.Reason)
+}
+
+// Validate checks the options for values and combinations robocopy doesn't accept.
+// It returns nil if everything is fine, or the joined list of *ValidationError values
+// otherwise. Use errors.As to get to the individual errors.
+func (r *Robocopy) Validate() error {
+	var errs []error
+	add := func(field, sw, reason string) {
+		errs = append(errs, &ValidationError{Field: field, Switch: sw, Reason: reason})
+	}
+	nonNegative := func(value int, field, sw string) {
+		if value < 0 {
+			add(field, sw, "must not be negative")
+		}
+	}
+	nonNegativeSize := func(value ByteSize, field, sw string) {
+		if value < 0 {
+			add(field, sw, "must not be negative")
+		}
+	}
+
+	if c := r.copyOpt; c != nil {
+		if c.S && c.E {
+			add("CopyOptions.S", "/s", "can't be used together with /e")
+		}
+		if c.Mov && c.Move {
+			add("CopyOptions.Mov", "/mov", "can't be used together with /move")
+		}
+		if c.Z && c.B {
+			add("CopyOptions.Z", "/z", "can't be used together with /b, use /zb instead")
+		}
+		if c.Zb && (c.Z || c.B) {
+			add("CopyOptions.Zb", "/zb", "can't be used together with /z or /b")
+		}
+		if c.Mt != 0 {
+			if c.Mt < 1 || c.Mt > 128 {
+				add("CopyOptions.Mt", "/mt", "must be between 1 and 128")
+			}
+			if c.Ipg != 0 {
+				add("CopyOptions.Mt", "/mt", "can't be used together with /ipg")
+			}
+			if c.EsfRaw {
+				add("CopyOptions.Mt", "/mt", "can't be used together with /efsraw")
+			}
+		}
+		if c.APlus&^aflags.Settable != 0 {
+			add("CopyOptions.APlus", "/a+", "can't set the offline attribute (O), which robocopy only takes in /a-, /ia and /xa")
+		}
+		if !c.Rh.IsZero() {
+			if reason := c.Rh.invalid(); reason != "" {
+				add("CopyOptions.Rh", "/rh", reason)
+			}
+		}
+		if c.NoMoreThan256 {
+			for _, path := range []struct{ name, path string }{{"source", r.source}, {"destination", r.destination}} {
+				if needsLongPaths(path.path) {
+					add("CopyOptions.NoMoreThan256", "/256", "turns off the long path support the "+path.name+" directory needs")
+				}
+			}
+		}
+		nonNegative(c.Lev, "CopyOptions.Lev", "/lev")
+		nonNegative(c.Mon, "CopyOptions.Mon", "/mon")
+		nonNegative(c.Mot, "CopyOptions.Mot", "/mot")
+		nonNegative(c.Ipg, "CopyOptions.Ipg", "/ipg")
+	}
+	if t := r.throttlingOpt; t != nil {
+		nonNegativeSize(t.Iomaxsize, "CopyFileThrottlingOptions.Iomaxsize", "/iomaxsize")
+		nonNegativeSize(t.Iorate, "CopyFileThrottlingOptions.Iorate", "/iorate")
+		nonNegativeSize(t.Threshold, "CopyFileThrottlingOptions.Threshold", "/threshold")
+		if t.Iorate > 0 && t.Iorate < MinIORate {
+			add("CopyFileThrottlingOptions.Iorate", "/iorate", "must be at least 512k, robocopy doesn't throttle below 524288 bytes per second")
+		}
+	}
+	if fso := r.fileslOpt; fso != nil {
+		if fso.A && fso.M {
+			add("FileSelectionOptions.A", "/a", "can't be used together with /m")
+		}
+		nonNegativeSize(fso.Max, "FileSelectionOptions.Max", "/max")
+		nonNegativeSize(fso.Min, "FileSelectionOptions.Min", "/min")
+		for _, filter := range []struct {
+			value     AgeFilter
+			field, sw string
+		}{
+			{fso.Maxage, "FileSelectionOptions.Maxage", "/maxage"},
+			{fso.Minage, "FileSelectionOptions.Minage", "/minage"},
+			{fso.Maxlad, "FileSelectionOptions.Maxlad", "/maxlad"},
+			{fso.Minlad, "FileSelectionOptions.Minlad", "/minlad"},
+		} {
+			if reason := filter.value.invalid(); reason != "" {
+				add(filter.field, filter.sw, reason)
+			}
+		}
+		// The maximum excludes the files older than its cutoff and the minimum the newer
+		// ones, so a maximum cutoff after the minimum one leaves nothing to copy.
+		now := time.Now()
+		if !fso.Maxage.IsZero() && !fso.Minage.IsZero() && fso.Maxage.Cutoff(now).After(fso.Minage.Cutoff(now)) {
+			add("FileSelectionOptions.Maxage", "/maxage", "excludes every file together with /minage")
+		}
+		if !fso.Maxlad.IsZero() && !fso.Minlad.IsZero() && fso.Maxlad.Cutoff(now).After(fso.Minlad.Cutoff(now)) {
+			add("FileSelectionOptions.Maxlad", "/maxlad", "excludes every file together with /minlad")
+		}
+	}
+	if ropt := r.retryOpt; ropt != nil {
+		nonNegative(ropt.R, "RetryOptions.R", "/r")
+		if ropt.W < 0 {
+			add("RetryOptions.W", "/w", "must not be negative")
+		} else if ropt.W%time.Second != 0 {
+			add("RetryOptions.W", "/w", "must be a whole number of seconds")
+		}
+		nonNegativeSize(ropt.LfsmSize, "RetryOptions.LfsmSize", "/lfsm")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package gorobocopy
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aggellos2001/go-robocopy/flags/aflags"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		copyOpt *CopyOptions
+		fileOpt *FileSelectionOptions
+		fields  []string
+	}{
+		{&CopyOptions{E: true, Mt: 16, Rh: RunWindow{Start: 22 * time.Hour, End: 6 * time.Hour}, Pf: true}, nil, nil},
+		{&CopyOptions{Mt: 4, Ipg: 10}, nil, []string{"CopyOptions.Mt"}},
+		{&CopyOptions{Mt: 4, EsfRaw: true}, nil, []string{"CopyOptions.Mt"}},
+		{&CopyOptions{Mt: 129}, nil, []string{"CopyOptions.Mt"}},
+		{&CopyOptions{Mt: -1}, nil, []string{"CopyOptions.Mt"}},
+		{&CopyOptions{S: true, E: true}, nil, []string{"CopyOptions.S"}},
+		{&CopyOptions{Mov: true, Move: true}, nil, []string{"CopyOptions.Mov"}},
+		{&CopyOptions{Z: true, B: true, Zb: true}, nil, []string{"CopyOptions.Z", "CopyOptions.Zb"}},
+		{&CopyOptions{Lev: -2}, nil, []string{"CopyOptions.Lev"}},
+		{&CopyOptions{Rh: RunWindow{Start: 22*time.Hour + 30*time.Second, End: 6 * time.Hour}}, nil, []string{"CopyOptions.Rh"}},
+		{&CopyOptions{Rh: RunWindow{Start: 25 * time.Hour, End: 6 * time.Hour}}, nil, []string{"CopyOptions.Rh"}},
+		{&CopyOptions{Rh: RunWindow{Start: 8 * time.Hour, End: 8 * time.Hour}}, nil, []string{"CopyOptions.Rh"}},
+		{&CopyOptions{Pf: true}, nil, nil},
+		{&CopyOptions{APlus: aflags.R | aflags.O, AMinus: aflags.O}, &FileSelectionOptions{Ia: aflags.O, Xa: aflags.O}, []string{"CopyOptions.APlus"}},
+		{nil, &FileSelectionOptions{A: true, M: true}, []string{"FileSelectionOptions.A"}},
+	}
+	for _, test := range tests {
+		r := NewRobocopy("C:\\source", "D:\\destination", "*.*")
+		r.SetCopyOptions(test.copyOpt)
+		r.SetFileSelectionOptions(test.fileOpt)
+		err := r.Validate()
+		if len(test.fields) == 0 {
+			if err != nil {
+				t.Errorf("%+v %+v: unexpected error: %v", test.copyOpt, test.fileOpt, err)
+			}
+			continue
+		}
+		var have []string
+		for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				have = append(have, verr.Field)
+			}
+		}
+		if len(have) != len(test.fields) {
+			t.Errorf("%+v %+v: have: %v, want: %v", test.copyOpt, test.fileOpt, have, test.fields)
+			continue
+		}
+		for i := range have {
+			if have[i] != test.fields[i] {
+				t.Errorf("%+v %+v: have: %v, want: %v", test.copyOpt, test.fileOpt, have, test.fields)
+			}
+		}
+	}
+}
+
+func TestRunRefusesInvalidOptions(t *testing.T) {
+	r := NewRobocopy("C:\\source", "D:\\destination", "*.*")
+	r.SetCopyOptions(&CopyOptions{Mt: 200})
+	var verr *ValidationError
+	if err := r.Run(nil, nil, nil); !errors.As(err, &verr) {
+		t.Errorf("have: %v, want a *ValidationError", err)
+	}
+}
+
+func TestValidateSizes(t *testing.T) {
+	tests := []struct {
+		throttling *CopyFileThrottlingOptions
+		fileOpt    *FileSelectionOptions
+		field      string
+	}{
+		{&CopyFileThrottlingOptions{Iorate: MinIORate, Iomaxsize: Kilobyte}, &FileSelectionOptions{Max: Gigabyte}, ""},
+		{&CopyFileThrottlingOptions{Iorate: 100 * Kilobyte}, nil, "CopyFileThrottlingOptions.Iorate"},
+		{&CopyFileThrottlingOptions{Threshold: -1}, nil, "CopyFileThrottlingOptions.Threshold"},
+		{nil, &FileSelectionOptions{Min: -Kilobyte}, "FileSelectionOptions.Min"},
+	}
+	for _, test := range tests {
+		r := NewRobocopy("C:\\source", "D:\\destination", "*.*")
+		r.SetThrottlingOptions(test.throttling)
+		r.SetFileSelectionOptions(test.fileOpt)
+		err := r.Validate()
+		var verr *ValidationError
+		switch {
+		case test.field == "" && err != nil:
+			t.Errorf("%+v %+v: unexpected error: %v", test.throttling, test.fileOpt, err)
+		case test.field != "" && (!errors.As(err, &verr) || verr.Field != test.field):
+			t.Errorf("%+v %+v: have: %v, want an error for %s", test.throttling, test.fileOpt, err, test.field)
+		}
+	}
+}
+
+func TestValidateAgeFilters(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		fileOpt *FileSelectionOptions
+		retry   *RetryOptions
+		fields  []string
+	}{
+		{&FileSelectionOptions{Maxage: AgeOf(30 * day), Minage: DateOf(time.Now().AddDate(0, 0, -7))}, &RetryOptions{W: 5 * time.Second}, nil},
+		{&FileSelectionOptions{Maxage: AgeOf(1900 * day)}, nil, []string{"FileSelectionOptions.Maxage"}},
+		{&FileSelectionOptions{Minlad: AgeOf(36 * time.Hour)}, nil, []string{"FileSelectionOptions.Minlad"}},
+		{&FileSelectionOptions{Maxlad: AgeOf(-day)}, nil, []string{"FileSelectionOptions.Maxlad"}},
+		{&FileSelectionOptions{Minage: DateOf(time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC))}, nil, []string{"FileSelectionOptions.Minage"}},
+		{&FileSelectionOptions{Maxage: AgeOf(2 * day), Minage: AgeOf(10 * day)}, nil, []string{"FileSelectionOptions.Maxage"}},
+		{&FileSelectionOptions{Maxlad: DateOf(time.Now()), Minlad: AgeOf(3 * day)}, nil, []string{"FileSelectionOptions.Maxlad"}},
+		{nil, &RetryOptions{W: 1500 * time.Millisecond}, []string{"RetryOptions.W"}},
+		{nil, &RetryOptions{W: -time.Second}, []string{"RetryOptions.W"}},
+	}
+	for _, test := range tests {
+		r := NewRobocopy("C:\\source", "D:\\destination", "*.*")
+		r.SetFileSelectionOptions(test.fileOpt)
+		r.SetRetryOptions(test.retry)
+		var have []string
+		if err := r.Validate(); err != nil {
+			for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var verr *ValidationError
+				if errors.As(err, &verr) {
+					have = append(have, verr.Field)
+				}
+			}
+		}
+		if !slices.Equal(have, test.fields) {
+			t.Errorf("%+v %+v: have: %v, want: %v", test.fileOpt, test.retry, have, test.fields)
+		}
+	}
+}